@@ -0,0 +1,183 @@
+package coachbuf
+
+import (
+	"bytes"
+	"math/big"
+	"net/netip"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// BitWriter writes values in Coachbuf wire format
+//
+// It wraps the same writer used by Encode, so values written with a BitWriter are bit-for-bit identical to
+// the ones written by Encode for a field with equivalent tags
+type BitWriter struct {
+	w *bitpacker.Writer
+}
+
+// NewBitWriter returns a BitWriter with an empty buffer
+func NewBitWriter() BitWriter {
+	return BitWriter{w: bitpacker.NewWriter()}
+}
+
+// WriteBits writes the lowest given number of bits of value, bits must be in the range (0,32]
+func (w BitWriter) WriteBits(value uint32, bits int) error {
+	return w.w.Write(value, bits)
+}
+
+// WriteBool writes a single bit
+func (w BitWriter) WriteBool(value bool) error {
+	if value {
+		return w.w.Write(1, 1)
+	}
+
+	return w.w.Write(0, 1)
+}
+
+// WriteInteger writes an integer in the range [min, max] where min != max
+func (w BitWriter) WriteInteger(value, min, max int32) error {
+	return coachwire.WriteInteger(w.w, value, min, max)
+}
+
+// WriteVarInteger writes an integer with the enc=varint encoding
+func (w BitWriter) WriteVarInteger(value int32) error {
+	return coachwire.WriteVarInteger(w.w, value)
+}
+
+// WriteGammaInteger writes an integer with the enc=gamma encoding
+func (w BitWriter) WriteGammaInteger(value int32) error {
+	return coachwire.WriteGammaInteger(w.w, value)
+}
+
+// WriteFloat writes a float32 with full precision
+func (w BitWriter) WriteFloat(value float32) error {
+	return coachwire.WriteFloat(w.w, value)
+}
+
+// WriteFloat64 writes a float64 with full precision
+func (w BitWriter) WriteFloat64(value float64) error {
+	return coachwire.WriteFloat64(w.w, value)
+}
+
+// WriteCompressedFloat writes a float32 in the range [min, max] with a precision of res
+func (w BitWriter) WriteCompressedFloat(value, min, max, res float32) error {
+	return coachwire.WriteCompressedFloat(w.w, value, min, max, res)
+}
+
+// WriteAddr writes an IPv4 or IPv6 address, the zero Addr and an address with a zone are rejected
+func (w BitWriter) WriteAddr(value netip.Addr) error {
+	return coachwire.WriteAddr(w.w, value)
+}
+
+// WriteAddrPort writes an IPv4 or IPv6 address and a port
+func (w BitWriter) WriteAddrPort(value netip.AddrPort) error {
+	return coachwire.WriteAddrPort(w.w, value)
+}
+
+// WriteBigInt writes an arbitrary-precision integer whose absolute value fits in maxBits bits
+func (w BitWriter) WriteBigInt(value *big.Int, maxBits int) error {
+	return coachwire.WriteBigInt(w.w, value, maxBits)
+}
+
+// WriteBytes writes a slice of at most maxLen bytes, the same way an encoding.BinaryMarshaler field with a maxlen tag is written
+func (w BitWriter) WriteBytes(value []byte, maxLen int) error {
+	return coachwire.WriteBytes(w.w, value, maxLen)
+}
+
+// NumBitsWritten returns the number of bits written so far
+func (w BitWriter) NumBitsWritten() int {
+	return w.w.NumBitsWritten()
+}
+
+// Stream returns a writing Stream over the same bits as the BitWriter
+func (w BitWriter) Stream() Stream {
+	return coachwire.NewWriteStream(w.w)
+}
+
+// Flush must be called ONLY once after the last write, the BitWriter can not be written to afterwards
+func (w BitWriter) Flush() error {
+	return w.w.FlushBits()
+}
+
+// Bytes returns the bytes written, only complete 32 bit words are included until Flush is called
+func (w BitWriter) Bytes() []byte {
+	return w.w.Bytes()
+}
+
+// BitReader reads values in Coachbuf wire format written by a BitWriter or by Encode
+type BitReader struct {
+	r *bitpacker.Reader
+}
+
+// NewBitReader returns a BitReader reading from data, the length of data must be a multiple of 4 bytes
+func NewBitReader(data []byte) BitReader {
+	return BitReader{r: bitpacker.NewReader(bytes.NewReader(data), len(data))}
+}
+
+// Stream returns a reading Stream over the same bits as the BitReader
+func (r BitReader) Stream() Stream {
+	return coachwire.NewReadStream(r.r)
+}
+
+// ReadBits reads the given number of bits, bits must be in the range (0,32]
+func (r BitReader) ReadBits(bits int) (uint32, error) {
+	return r.r.Read(bits)
+}
+
+// ReadBool reads a single bit
+func (r BitReader) ReadBool() (bool, error) {
+	value, err := r.r.Read(1)
+	return value == 1, err
+}
+
+// ReadInteger reads an integer in the range [min, max] where min != max
+func (r BitReader) ReadInteger(min, max int32) (int32, error) {
+	return coachwire.ReadInteger(r.r, min, max)
+}
+
+// ReadVarInteger reads an integer with the enc=varint encoding
+func (r BitReader) ReadVarInteger() (int32, error) {
+	return coachwire.ReadVarInteger(r.r)
+}
+
+// ReadGammaInteger reads an integer with the enc=gamma encoding
+func (r BitReader) ReadGammaInteger() (int32, error) {
+	return coachwire.ReadGammaInteger(r.r)
+}
+
+// ReadFloat reads a float32 with full precision
+func (r BitReader) ReadFloat() (float32, error) {
+	return coachwire.ReadFloat(r.r)
+}
+
+// ReadFloat64 reads a float64 with full precision
+func (r BitReader) ReadFloat64() (float64, error) {
+	return coachwire.ReadFloat64(r.r)
+}
+
+// ReadCompressedFloat reads a float32 in the range [min, max] with a precision of res
+func (r BitReader) ReadCompressedFloat(min, max, res float32) (float32, error) {
+	return coachwire.ReadCompressedFloat(r.r, min, max, res)
+}
+
+// ReadAddr reads an IPv4 or IPv6 address
+func (r BitReader) ReadAddr() (netip.Addr, error) {
+	return coachwire.ReadAddr(r.r)
+}
+
+// ReadAddrPort reads an IPv4 or IPv6 address and a port
+func (r BitReader) ReadAddrPort() (netip.AddrPort, error) {
+	return coachwire.ReadAddrPort(r.r)
+}
+
+// ReadBigInt reads an arbitrary-precision integer whose absolute value fits in maxBits bits
+func (r BitReader) ReadBigInt(maxBits int) (*big.Int, error) {
+	return coachwire.ReadBigInt(r.r, maxBits)
+}
+
+// ReadBytes reads a slice of at most maxLen bytes written by WriteBytes
+func (r BitReader) ReadBytes(maxLen int) ([]byte, error) {
+	return coachwire.ReadBytes(r.r, maxLen)
+}
@@ -0,0 +1,597 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const coachbufPath = "github.com/trphume/coachbuf"
+
+// generator emits the EncodeCoachbuf and DecodeCoachbuf methods of struct types from a single package
+type generator struct {
+	pkg     *types.Package
+	buf     bytes.Buffer
+	imports map[string]string // import path to package name
+	depth   int               // nesting level of the struct being decoded, used to name loop variables
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	return &generator{
+		pkg:     pkg,
+		imports: map[string]string{"fmt": "fmt", coachbufPath: "coachbuf"},
+	}
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// qualifier names types of other packages with their package name and records the import
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+	g.imports[p.Path()] = p.Name()
+
+	return p.Name()
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// generate emits the methods of the named struct type
+func (g *generator) generate(typeName string) error {
+	obj := g.pkg.Scope().Lookup(typeName)
+	if obj == nil {
+		return fmt.Errorf("type %s not found in package %s", typeName, g.pkg.Name())
+	}
+	if _, ok := obj.(*types.TypeName); !ok {
+		return fmt.Errorf("%s is not a type", typeName)
+	}
+
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("type %s is not a struct", typeName)
+	}
+
+	g.printf("// EncodeCoachbuf writes v in Coachbuf format, the output is identical to coachbuf.Encode\n")
+	g.printf("func (v %s) EncodeCoachbuf(w coachbuf.BitWriter) error {\n", typeName)
+	if err := g.encodeStruct(st, "v", ""); err != nil {
+		return fmt.Errorf("type %s: %w", typeName, err)
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("// DecodeCoachbuf reads v in Coachbuf format, the input is read the same way as coachbuf.Decode\n")
+	g.printf("func (v *%s) DecodeCoachbuf(r coachbuf.BitReader) error {\n", typeName)
+	if err := g.decodeStruct(obj.Type(), st, "v", ""); err != nil {
+		return fmt.Errorf("type %s: %w", typeName, err)
+	}
+	g.printf("return nil\n}\n\n")
+
+	return nil
+}
+
+// source returns the formatted file holding every generated method
+func (g *generator) source() ([]byte, error) {
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by coachbuf-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&file, "package %s\n\nimport (\n", g.pkg.Name())
+
+	// standard library imports are grouped before the others
+	for _, std := range [2]bool{true, false} {
+		for _, path := range paths {
+			if isStandard(path) == std {
+				fmt.Fprintf(&file, "%q\n", path)
+			}
+		}
+		if std {
+			fmt.Fprintf(&file, "\n")
+		}
+	}
+	fmt.Fprintf(&file, ")\n\n")
+	file.Write(g.buf.Bytes())
+
+	return format.Source(file.Bytes())
+}
+
+// taggedField is a struct field holding a coachbuf tag
+type taggedField struct {
+	field *types.Var
+	tags  []string
+	order int32
+}
+
+// taggedFields returns the fields of a struct holding a coachbuf tag in declaration order
+// or sorted by ordering number for a positional struct, and whether the struct is positional
+func (g *generator) taggedFields(st *types.Struct) ([]taggedField, bool, error) {
+	var fields []taggedField
+	var positional bool
+	seen := make(map[int32]bool)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if field.Name() == "_" {
+			// struct options other than positional and versioned, such as maxbits, are checked by coachbuf.NewCodec
+			structTag := reflect.StructTag(st.Tag(i)).Get("coachbuf")
+			if hasStructOption(structTag, "versioned") {
+				return nil, false, fmt.Errorf("versioned struct option is not supported, use coachbuf.EncodeOptions instead")
+			}
+			positional = positional || hasStructOption(structTag, "positional")
+			continue
+		}
+		tags, order, err := parseTag(field.Name(), reflect.StructTag(st.Tag(i)).Get("coachbuf"))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(tags) < 1 {
+			continue
+		}
+
+		if seen[order] {
+			return nil, false, fmt.Errorf("ordering number is used for more than one field, field=%s", field.Name())
+		}
+		seen[order] = true
+
+		if !field.Exported() {
+			return nil, false, fmt.Errorf("field=%s must be exported to be decoded", field.Name())
+		}
+		if field.Type() == types.Typ[types.Invalid] {
+			return nil, false, fmt.Errorf("could not resolve type of field=%s", field.Name())
+		}
+
+		fields = append(fields, taggedField{field: field, tags: tags, order: order})
+	}
+
+	if positional {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].order < fields[j].order })
+	}
+
+	return fields, positional, nil
+}
+
+// check emits a call returning only an error, the error is returned wrapped with the path of the field
+func (g *generator) check(call, errPrefix string) {
+	g.printf("if err := %s; err != nil {\nreturn fmt.Errorf(%s, err)\n}\n", call, strconv.Quote(errPrefix+"%w"))
+}
+
+// errReturn emits a return of the err variable wrapped with the path of the field
+func (g *generator) errReturn(errPrefix string) {
+	g.printf("if err != nil {\nreturn fmt.Errorf(%s, err)\n}\n", strconv.Quote(errPrefix+"%w"))
+}
+
+func (g *generator) encodeStruct(st *types.Struct, expr, errPrefix string) error {
+	fields, positional, err := g.taggedFields(st)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		prefix := errPrefix + "field=" + f.field.Name() + ": "
+		if err := g.checkConstraints(f.field.Type(), expr+"."+f.field.Name(), f.tags, prefix); err != nil {
+			return fmt.Errorf("field=%s: %w", f.field.Name(), err)
+		}
+		if !positional {
+			g.check(fmt.Sprintf("w.WriteInteger(%d, %d, %d)", f.order, minOrderingNumber, maxOrderingNumber), prefix)
+		}
+		if err := g.encodeValue(f.field.Type(), expr+"."+f.field.Name(), f.tags, prefix); err != nil {
+			return fmt.Errorf("field=%s: %w", f.field.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) encodeValue(t types.Type, expr string, tags []string, errPrefix string) error {
+	if inner, ok := optionalArg(t); ok {
+		g.check(fmt.Sprintf("w.WriteBool(%s.Valid)", expr), errPrefix)
+		g.printf("if %s.Valid {\n", expr)
+		if err := g.encodeValue(inner, expr+".Value", tags, errPrefix); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+
+	switch {
+	case isNamed(t, "net/netip", "Addr"):
+		g.check(fmt.Sprintf("w.WriteAddr(%s)", expr), errPrefix)
+		return nil
+	case isNamed(t, "net/netip", "AddrPort"):
+		g.check(fmt.Sprintf("w.WriteAddrPort(%s)", expr), errPrefix)
+		return nil
+	case isBigInt(t):
+		maxBits, err := parseMaxBits(tags)
+		if err != nil {
+			return err
+		}
+		g.check(fmt.Sprintf("w.WriteBigInt(%s, %d)", expr, maxBits), errPrefix)
+		return nil
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		return g.encodeStruct(u, expr, errPrefix)
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Int32:
+			return g.encodeInt32(t, expr, tags, errPrefix)
+		case types.Float32:
+			g.check(fmt.Sprintf("w.WriteFloat(%s)", convert(t, "float32", expr)), errPrefix)
+			return nil
+		case types.Complex64, types.Complex128:
+			return g.encodeComplex(u.Kind(), expr, tags, errPrefix)
+		}
+	}
+
+	return fmt.Errorf("unsupported type=%s", g.typeString(t))
+}
+
+func (g *generator) encodeInt32(t types.Type, expr string, tags []string, errPrefix string) error {
+	enc, err := parseEncoding(tags)
+	if err != nil {
+		return err
+	}
+
+	value := convert(t, "int32", expr)
+	switch enc {
+	case "varint":
+		g.check(fmt.Sprintf("w.WriteVarInteger(%s)", value), errPrefix)
+	case "gamma":
+		g.check(fmt.Sprintf("w.WriteGammaInteger(%s)", value), errPrefix)
+	default:
+		min, max, err := parseIntRange(tags)
+		if err != nil {
+			return err
+		}
+		g.check(fmt.Sprintf("w.WriteInteger(%s, %d, %d)", value, min, max), errPrefix)
+	}
+
+	return nil
+}
+
+func (g *generator) encodeComplex(kind types.BasicKind, expr string, tags []string, errPrefix string) error {
+	min, max, res, compressed, err := parseFloatRange(tags)
+	if err != nil {
+		return err
+	}
+
+	for _, part := range [2]string{"real", "imag"} {
+		value := part + "(" + expr + ")"
+		switch {
+		case compressed:
+			if kind == types.Complex128 {
+				value = "float32(" + value + ")"
+			}
+			g.check(fmt.Sprintf("w.WriteCompressedFloat(%s, %s, %s, %s)",
+				value, formatFloat32(min), formatFloat32(max), formatFloat32(res)), errPrefix)
+		case kind == types.Complex64:
+			g.check(fmt.Sprintf("w.WriteFloat(%s)", value), errPrefix)
+		default:
+			g.check(fmt.Sprintf("w.WriteFloat64(%s)", value), errPrefix)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) decodeStruct(t types.Type, st *types.Struct, expr, errPrefix string) error {
+	fields, positional, err := g.taggedFields(st)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	// fields of a positional struct follow each other without ordering numbers
+	if positional {
+		for _, f := range fields {
+			if err := g.decodeField(f, expr, errPrefix); err != nil {
+				return err
+			}
+		}
+		g.validate(t, expr, errPrefix)
+		return nil
+	}
+
+	loop, order := "i"+strconv.Itoa(g.depth), "order"+strconv.Itoa(g.depth)
+	g.depth++
+	defer func() { g.depth-- }()
+
+	g.printf("for %s := 0; %s < %d; %s++ {\n", loop, loop, len(fields), loop)
+	g.printf("%s, err := r.ReadInteger(%d, %d)\n", order, minOrderingNumber, maxOrderingNumber)
+	g.errReturn(errPrefix + "error reading ordering number: ")
+	g.printf("switch %s {\n", order)
+	for _, f := range fields {
+		g.printf("case %d:\n", f.order)
+		if err := g.decodeField(f, expr, errPrefix); err != nil {
+			return err
+		}
+	}
+	g.printf("default:\nreturn fmt.Errorf(%s, %s)\n}\n}\n",
+		strconv.Quote(errPrefix+"order=%d is not used by type="+escapePercent(g.typeString(t))), order)
+	g.validate(t, expr, errPrefix)
+
+	return nil
+}
+
+// decodeField emits the decoding of the field f of the struct expr followed by the checks of its constraint tags
+func (g *generator) decodeField(f taggedField, expr, errPrefix string) error {
+	prefix := errPrefix + "field=" + f.field.Name() + ": "
+	if err := g.decodeValue(f.field.Type(), expr+"."+f.field.Name(), f.tags, prefix); err != nil {
+		return fmt.Errorf("field=%s: %w", f.field.Name(), err)
+	}
+	if err := g.checkConstraints(f.field.Type(), expr+"."+f.field.Name(), f.tags, prefix); err != nil {
+		return fmt.Errorf("field=%s: %w", f.field.Name(), err)
+	}
+
+	return nil
+}
+
+// validate emits a call of the Validate method of a decoded struct when a pointer to its type implements coachbuf.Validator
+func (g *generator) validate(t types.Type, expr, errPrefix string) {
+	if !types.Implements(types.NewPointer(t), validatorInterface) {
+		return
+	}
+
+	g.check(expr+".Validate()", errPrefix+"type="+escapePercent(g.typeString(t))+" failed validation: ")
+}
+
+// validatorInterface is the method set of coachbuf.Validator
+var validatorInterface = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Validate", types.NewSignatureType(nil, nil, nil, nil,
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type())), false)),
+}, nil).Complete()
+
+// checkConstraints emits the checks of the nonzero and oneof tags of the value expr of type t,
+// the value of an Optional is only checked when present
+func (g *generator) checkConstraints(t types.Type, expr string, tags []string, errPrefix string) error {
+	c, found, err := parseConstraints(tags)
+	if err != nil || !found {
+		return err
+	}
+
+	if inner, ok := optionalArg(t); ok {
+		g.printf("if %s.Valid {\n", expr)
+		if err := g.checkConstraint(inner, expr+".Value", c, errPrefix); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+
+	return g.checkConstraint(t, expr, c, errPrefix)
+}
+
+func (g *generator) checkConstraint(t types.Type, expr string, c constraints, errPrefix string) error {
+	// every type supported by the generator has no length
+	if c.minLen > 0 {
+		return fmt.Errorf("minlen tag requires a string, slice or map value, type=%s", g.typeString(t))
+	}
+
+	basic, _ := t.Underlying().(*types.Basic)
+	var mismatches []string
+	for _, value := range c.oneOf {
+		switch {
+		case basic != nil && basic.Kind() == types.Int32:
+			i, err := strconv.ParseInt(value, 10, 32)
+			if err != nil {
+				return fmt.Errorf("oneof tag values must be int32 numbers, value=%s", value)
+			}
+			mismatches = append(mismatches, expr+" != "+strconv.FormatInt(i, 10))
+		case basic != nil && basic.Kind() == types.Float32:
+			f, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				return fmt.Errorf("oneof tag values must be float32 numbers, value=%s", value)
+			}
+			mismatches = append(mismatches, expr+" != "+formatFloat32(float32(f)))
+		default:
+			return fmt.Errorf("oneof tag requires an int32 or float32 value, type=%s", g.typeString(t))
+		}
+	}
+
+	if c.nonZero {
+		g.printf("if %s == %s {\nreturn fmt.Errorf(%s, coachbuf.ErrConstraintViolated)\n}\n", expr, g.zero(t),
+			strconv.Quote(errPrefix+"value of type="+escapePercent(g.typeString(t))+" must be nonzero: %w"))
+	}
+	if mismatches != nil {
+		g.printf("if %s {\nreturn fmt.Errorf(%s, %s, coachbuf.ErrConstraintViolated)\n}\n", strings.Join(mismatches, " && "),
+			strconv.Quote(errPrefix+"value=%v must be one of "+escapePercent(fmt.Sprint(c.oneOf))+": %w"), expr)
+	}
+
+	return nil
+}
+
+// zero returns the zero value of type t, compared with == it gives the same result as reflect.Value.IsZero
+func (g *generator) zero(t types.Type) string {
+	switch t.Underlying().(type) {
+	case *types.Basic:
+		return "0"
+	case *types.Pointer:
+		return "nil"
+	}
+
+	return "(" + g.typeString(t) + "{})"
+}
+
+func (g *generator) decodeValue(t types.Type, expr string, tags []string, errPrefix string) error {
+	if inner, ok := optionalArg(t); ok {
+		g.printf("{\npresent, err := r.ReadBool()\n")
+		g.errReturn(errPrefix)
+		g.printf("if !present {\n%s = %s{}\n} else {\n%s.Valid = true\n", expr, g.typeString(t), expr)
+		if err := g.decodeValue(inner, expr+".Value", tags, errPrefix); err != nil {
+			return err
+		}
+		g.printf("}\n}\n")
+		return nil
+	}
+
+	switch {
+	case isNamed(t, "net/netip", "Addr"):
+		return g.decodeAssign(t, expr, "r.ReadAddr()", errPrefix)
+	case isNamed(t, "net/netip", "AddrPort"):
+		return g.decodeAssign(t, expr, "r.ReadAddrPort()", errPrefix)
+	case isBigInt(t):
+		maxBits, err := parseMaxBits(tags)
+		if err != nil {
+			return err
+		}
+		return g.decodeAssign(t, expr, fmt.Sprintf("r.ReadBigInt(%d)", maxBits), errPrefix)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		return g.decodeStruct(t, u, expr, errPrefix)
+	case *types.Basic:
+		switch u.Kind() {
+		case types.Int32:
+			return g.decodeInt32(t, expr, tags, errPrefix)
+		case types.Float32:
+			return g.decodeAssign(t, expr, "r.ReadFloat()", errPrefix)
+		case types.Complex64, types.Complex128:
+			return g.decodeComplex(t, u.Kind(), expr, tags, errPrefix)
+		}
+	}
+
+	return fmt.Errorf("unsupported type=%s", g.typeString(t))
+}
+
+// decodeAssign emits a call returning a value and an error, the value is converted to the type of expr
+func (g *generator) decodeAssign(t types.Type, expr, call, errPrefix string) error {
+	g.printf("{\nx, err := %s\n", call)
+	g.errReturn(errPrefix)
+	g.printf("%s = %s\n}\n", expr, g.convertTo(t, "x"))
+
+	return nil
+}
+
+func (g *generator) decodeInt32(t types.Type, expr string, tags []string, errPrefix string) error {
+	enc, err := parseEncoding(tags)
+	if err != nil {
+		return err
+	}
+
+	switch enc {
+	case "varint":
+		return g.decodeAssign(t, expr, "r.ReadVarInteger()", errPrefix)
+	case "gamma":
+		return g.decodeAssign(t, expr, "r.ReadGammaInteger()", errPrefix)
+	}
+
+	min, max, err := parseIntRange(tags)
+	if err != nil {
+		return err
+	}
+
+	return g.decodeAssign(t, expr, fmt.Sprintf("r.ReadInteger(%d, %d)", min, max), errPrefix)
+}
+
+func (g *generator) decodeComplex(t types.Type, kind types.BasicKind, expr string, tags []string, errPrefix string) error {
+	min, max, res, compressed, err := parseFloatRange(tags)
+	if err != nil {
+		return err
+	}
+
+	var call, value string
+	switch {
+	case compressed:
+		call = fmt.Sprintf("r.ReadCompressedFloat(%s, %s, %s)", formatFloat32(min), formatFloat32(max), formatFloat32(res))
+		value = "complex(re, im)"
+		if kind == types.Complex128 {
+			value = "complex(float64(re), float64(im))"
+		}
+	case kind == types.Complex64:
+		call, value = "r.ReadFloat()", "complex(re, im)"
+	default:
+		call, value = "r.ReadFloat64()", "complex(re, im)"
+	}
+
+	g.printf("{\nre, err := %s\n", call)
+	g.errReturn(errPrefix)
+	g.printf("im, err := %s\n", call)
+	g.errReturn(errPrefix)
+	g.printf("%s = %s\n}\n", expr, g.convertTo(t, value))
+
+	return nil
+}
+
+// convertTo converts value to type t when t is a named type
+func (g *generator) convertTo(t types.Type, value string) string {
+	if _, ok := t.(*types.Named); ok && !isNamed(t, "net/netip", "Addr") && !isNamed(t, "net/netip", "AddrPort") {
+		return g.typeString(t) + "(" + value + ")"
+	}
+
+	return value
+}
+
+// convert converts expr to the basic type name when t is a named type
+func convert(t types.Type, name, expr string) string {
+	if _, ok := t.(*types.Named); ok {
+		return name + "(" + expr + ")"
+	}
+
+	return expr
+}
+
+// optionalArg returns the type argument of a coachbuf.Optional
+func optionalArg(t types.Type) (types.Type, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || !isNamed(named.Origin(), coachbufPath, "Optional") || named.TypeArgs().Len() != 1 {
+		return nil, false
+	}
+
+	return named.TypeArgs().At(0), true
+}
+
+// isNamed reports whether t is the named type path.name
+func isNamed(t types.Type, path, name string) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+
+	return obj.Pkg() != nil && obj.Pkg().Path() == path && obj.Name() == name
+}
+
+// isBigInt reports whether t is *big.Int
+func isBigInt(t types.Type) bool {
+	pointer, ok := t.(*types.Pointer)
+	return ok && isNamed(pointer.Elem(), "math/big", "Int")
+}
+
+// formatFloat32 formats f so that parsing it as a float32 constant gives back f exactly
+func formatFloat32(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
+
+// isStandard reports whether an import path belongs to the standard library
+func isStandard(path string) bool {
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
+
+// escapePercent escapes a string used as part of a format string
+func escapePercent(s string) string {
+	var b bytes.Buffer
+	for _, r := range s {
+		if r == '%' {
+			b.WriteRune('%')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
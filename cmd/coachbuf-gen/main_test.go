@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedFileUpToDate regenerates the methods of internal/gentest and compares them with the committed file
+func TestGeneratedFileUpToDate(t *testing.T) {
+	dir := filepath.Join("..", "..", "internal", "gentest")
+	output := filepath.Join(dir, "gentest_coachbuf.go")
+
+	want, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("ReadFile() = %v, want %v", err.Error(), nil)
+	}
+
+	result, err := run(dir, output, []string{"Player", "Header", "Unit"})
+	if err != nil {
+		t.Fatalf("run() = %v, want %v", err.Error(), nil)
+	}
+	if string(result) != string(want) {
+		t.Errorf("run() output differs from %s, run go generate ./...", output)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "duplicate ordering number",
+			src:  "type T struct {\nA int32 `coachbuf:\"1\"`\nB int32 `coachbuf:\"1\"`\n}",
+			want: "used for more than one field",
+		},
+		{
+			name: "invalid range",
+			src:  "type T struct {\nA int32 `coachbuf:\"1,min=5,max=5\"`\n}",
+			want: "must be less than",
+		},
+		{
+			name: "unsupported type",
+			src:  "type T struct {\nA string `coachbuf:\"1\"`\n}",
+			want: "unsupported type=string",
+		},
+		{
+			name: "not a struct",
+			src:  "type T int32",
+			want: "is not a struct",
+		},
+		{
+			name: "compressed range too wide",
+			src:  "type T struct {\nA complex64 `coachbuf:\"1,min=-1000000,max=1000000,res=0.000001\"`\n}",
+			want: "steps, at most",
+		},
+		{
+			name: "minlen tag",
+			src:  "type T struct {\nA int32 `coachbuf:\"1,minlen=1\"`\n}",
+			want: "minlen tag requires",
+		},
+		{
+			name: "oneof tag on a complex value",
+			src:  "type T struct {\nA complex64 `coachbuf:\"1,oneof=1|2\"`\n}",
+			want: "oneof tag requires an int32 or float32 value",
+		},
+		{
+			name: "invalid oneof value",
+			src:  "type T struct {\nA int32 `coachbuf:\"1,oneof=1|x\"`\n}",
+			want: "oneof tag values must be int32 numbers",
+		},
+		{
+			name: "versioned struct",
+			src:  "type T struct {\n_ struct{} `coachbuf:\"versioned\"`\nA int32 `coachbuf:\"1\"`\n}",
+			want: "versioned struct option is not supported",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "t.go"), []byte("package p\n\n"+tt.src+"\n"), 0o600); err != nil {
+				t.Fatalf("WriteFile() = %v, want %v", err.Error(), nil)
+			}
+
+			_, err := run(dir, filepath.Join(dir, "t_coachbuf.go"), []string{"T"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("run() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
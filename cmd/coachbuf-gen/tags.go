@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// the tag parsing below mirrors the helpers used by the reflection path of coachbuf
+// any difference in behavior is caught by the tests comparing generated output with coachbuf.Encode
+
+const (
+	minOrderingNumber = 0
+	maxOrderingNumber = 255
+)
+
+// parseTag splits a coachbuf struct tag and returns its values and the ordering number
+func parseTag(fieldName, structTag string) ([]string, int32, error) {
+	if structTag == "" {
+		return nil, 0, nil
+	}
+
+	tags := strings.Split(structTag, ",")
+	order, err := strconv.ParseInt(tags[0], 10, 32)
+	if err != nil {
+		return nil, 0, fmt.Errorf("ordering number must be first value in the comma separated tag, field=%s", fieldName)
+	}
+	if order < minOrderingNumber || order > maxOrderingNumber {
+		return nil, 0, fmt.Errorf("ordering number not within accepted range, field=%s, order=%d", fieldName, order)
+	}
+
+	return tags, int32(order), nil
+}
+
+// lookupTag finds the value of the first key=value tag
+func lookupTag(tags []string, key string) (string, bool) {
+	prefix := key + "="
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, prefix) {
+			return tag[len(prefix):], true
+		}
+	}
+
+	return "", false
+}
+
+// parseEncoding returns the value of the enc tag, an empty string means the bounded range encoding
+func parseEncoding(tags []string) (string, error) {
+	var enc string
+	var ranged bool
+	for _, tag := range tags {
+		switch {
+		case strings.HasPrefix(tag, "enc="):
+			enc = tag[4:]
+			if enc != "varint" && enc != "gamma" {
+				return "", fmt.Errorf("enc tag value must be varint or gamma, tag=%s", tag)
+			}
+		case strings.HasPrefix(tag, "max=") || strings.HasPrefix(tag, "min="):
+			ranged = true
+		}
+	}
+
+	if enc != "" && ranged {
+		return "", fmt.Errorf("enc tag can not be combined with min and max tags, enc=%s", enc)
+	}
+
+	return enc, nil
+}
+
+// parseIntRange returns the int32 min and max tags
+func parseIntRange(tags []string) (int32, int32, error) {
+	min, max := int32(math.MinInt32), int32(math.MaxInt32)
+
+	var minSet, maxSet bool
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, "max=") && !strings.HasPrefix(tag, "min=") {
+			continue
+		}
+		if minSet && maxSet {
+			break
+		}
+
+		value, err := strconv.ParseInt(tag[4:], 10, 32)
+		if err != nil {
+			return 0, 0, fmt.Errorf("min and max tag value must be a int32 number, tag=%s", tag)
+		}
+		if tag[:4] == "max=" {
+			max, maxSet = int32(value), true
+		} else {
+			min, minSet = int32(value), true
+		}
+	}
+
+	if min >= max {
+		return 0, 0, fmt.Errorf("min=%d must be less than max=%d", min, max)
+	}
+
+	return min, max, nil
+}
+
+// parseFloatRange returns the float32 min, max and res tags and whether they are given
+func parseFloatRange(tags []string) (float32, float32, float32, bool, error) {
+	if _, ok := lookupTag(tags, "res"); !ok {
+		return 0, 0, 0, false, nil
+	}
+
+	var values [3]float32
+	for i, key := range [3]string{"min", "max", "res"} {
+		value, ok := lookupTag(tags, key)
+		if !ok {
+			return 0, 0, 0, false, fmt.Errorf("%s tag is required with res tag", key)
+		}
+
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return 0, 0, 0, false, fmt.Errorf("%s tag value must be a float32 number, tag=%s=%s", key, key, value)
+		}
+		values[i] = float32(f)
+	}
+
+	if values[2] <= 0 {
+		return 0, 0, 0, false, fmt.Errorf("res tag value must be positive")
+	}
+	if values[0] >= values[1] {
+		return 0, 0, 0, false, fmt.Errorf("min=%v must be less than max=%v", values[0], values[1])
+	}
+	if steps := math.Ceil(float64((values[1] - values[0]) / values[2])); !(steps <= math.MaxUint32) {
+		return 0, 0, 0, false, fmt.Errorf("min=%v, max=%v and res=%v require %v steps, at most %d", values[0], values[1], values[2], steps, uint32(math.MaxUint32))
+	}
+
+	return values[0], values[1], values[2], true, nil
+}
+
+// parseMaxBits returns the required maxbits tag
+func parseMaxBits(tags []string) (int, error) {
+	value, ok := lookupTag(tags, "maxbits")
+	if !ok {
+		return 0, fmt.Errorf("maxbits tag is required")
+	}
+
+	maxBits, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || maxBits <= 0 {
+		return 0, fmt.Errorf("maxbits tag value must be a positive int32 number, tag=maxbits=%s", value)
+	}
+
+	return int(maxBits), nil
+}
+
+// constraints holds the nonzero, oneof and minlen tags of a field
+type constraints struct {
+	nonZero bool
+	oneOf   []string
+	minLen  int
+}
+
+// parseConstraints returns the constraint tags and whether any of them is given
+func parseConstraints(tags []string) (constraints, bool, error) {
+	var c constraints
+	var found bool
+	for _, tag := range tags {
+		if tag == "nonzero" {
+			c.nonZero, found = true, true
+		}
+	}
+
+	if value, ok := lookupTag(tags, "oneof"); ok {
+		if value == "" {
+			return c, false, fmt.Errorf("oneof tag value missing, tag=oneof=")
+		}
+		c.oneOf, found = strings.Split(value, "|"), true
+	}
+
+	if value, ok := lookupTag(tags, "minlen"); ok {
+		minLen, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || minLen <= 0 {
+			return c, false, fmt.Errorf("minlen tag value must be a positive int32 number, tag=minlen=%s", value)
+		}
+		c.minLen, found = int(minLen), true
+	}
+
+	return c, found, nil
+}
+
+// hasStructOption reports whether the tag of a blank field holds the given struct option
+func hasStructOption(structTag, option string) bool {
+	for _, tag := range strings.Split(structTag, ",") {
+		if tag == option {
+			return true
+		}
+	}
+
+	return false
+}
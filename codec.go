@@ -0,0 +1,62 @@
+package coachbuf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// Codec encodes and decodes values of type T in Coachbuf format
+//
+// Every tag, ordering number and range of T is validated when the Codec is created,
+// so mistakes surface at construction rather than at the first Encode or Decode
+type Codec[T any] struct {
+	tc *typeCodec
+}
+
+// NewCodec returns a Codec for type T or the first error found in the tags of T and its nested structs
+func NewCodec[T any]() (*Codec[T], error) {
+	tc := codecFor(reflect.TypeOf((*T)(nil)).Elem())
+	if tc.err != nil {
+		return nil, tc.err
+	}
+
+	return &Codec[T]{tc: tc}, nil
+}
+
+// Encode serializes v into a slice of byte in Coachbuf format
+func (c *Codec[T]) Encode(v T) ([]byte, error) {
+	return c.EncodeWithOptions(v, EncodeOptions{})
+}
+
+// EncodeWithOptions serializes v into a slice of byte in Coachbuf format with the given options
+func (c *Codec[T]) EncodeWithOptions(v T, opts EncodeOptions) ([]byte, error) {
+	writer := bitpacker.NewWriter()
+	if err := encodeWith(writer, c.tc, reflect.ValueOf(&v).Elem(), opts); err != nil {
+		return nil, err
+	}
+
+	return writer.Bytes(), nil
+}
+
+// Decode deserializes data into the value v
+// argument v must be a non-nil pointer
+func (c *Codec[T]) Decode(data []byte, v *T) error {
+	return c.DecodeWithOptions(data, v, DecodeOptions{})
+}
+
+// DecodeWithOptions deserializes data into the value v with the given options
+// argument v must be a non-nil pointer
+func (c *Codec[T]) DecodeWithOptions(data []byte, v *T, opts DecodeOptions) error {
+	if v == nil {
+		return fmt.Errorf("nil *%v: %w", reflect.TypeOf(v).Elem(), ErrInvalidDecodeTarget)
+	}
+	if len(data)%4 != 0 {
+		return fmt.Errorf("length=%d: %w", len(data), ErrInvalidDataLength)
+	}
+
+	reader := bitpacker.NewReader(bytes.NewReader(data), len(data))
+	return decodeWith(reader, c.tc, reflect.ValueOf(v).Elem(), opts)
+}
@@ -0,0 +1,75 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+func TestCodec(t *testing.T) {
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		type Player struct {
+			Health int32                    `coachbuf:"1,min=0,max=100"`
+			Score  int32                    `coachbuf:"2,enc=gamma"`
+			Speed  coachbuf.Optional[int32] `coachbuf:"3,min=0,max=10"`
+		}
+
+		c, err := coachbuf.NewCodec[Player]()
+		if err != nil {
+			t.Fatalf("NewCodec() = %v, want %v", err.Error(), nil)
+		}
+
+		input := Player{Health: 75, Score: 9001, Speed: coachbuf.Some[int32](4)}
+		data, err := c.Encode(input)
+		if err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		// output must be identical to the untyped Encode
+		want, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if string(data) != string(want) {
+			t.Errorf("Encode() = %v, want %v", data, want)
+		}
+
+		var result Player
+		if err := c.Decode(data, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("NewCodec validation", func(t *testing.T) {
+		t.Parallel()
+
+		type DuplicateOrdering struct {
+			First  int32 `coachbuf:"1"`
+			Second int32 `coachbuf:"1"`
+		}
+		type NestedInvalidRange struct {
+			Nested struct {
+				Int32 int32 `coachbuf:"1,min=100,max=0"`
+			} `coachbuf:"1"`
+		}
+		type UnsupportedType struct {
+			String string `coachbuf:"1"`
+		}
+
+		if _, err := coachbuf.NewCodec[DuplicateOrdering](); !errors.Is(err, coachbuf.ErrDuplicateOrdering) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrDuplicateOrdering)
+		}
+		if _, err := coachbuf.NewCodec[NestedInvalidRange](); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+		if _, err := coachbuf.NewCodec[UnsupportedType](); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+		}
+	})
+}
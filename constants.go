@@ -4,4 +4,19 @@ const (
 	cbStructTagsKey     = "coachbuf"
 	cbMinOrderingNumber = 0
 	cbMaxOrderingNumber = 255
+
+	// cbMaxDecodeDepth is the maximum number of nested structs decoded within a single value
+	cbMaxDecodeDepth = 64
+
+	// cbMaxDecodeAlloc is the maximum number of bytes of blobs and *big.Int allocated when decoding a single value
+	cbMaxDecodeAlloc = 1 << 20
+
+	// cbDefaultMaxLen is the maximum number of bytes of a blob when the maxlen tag is not given
+	cbDefaultMaxLen = 1024
+)
+
+// values accepted by the enc tag
+const (
+	cbEncodingVarint = "varint"
+	cbEncodingGamma  = "gamma"
 )
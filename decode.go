@@ -2,9 +2,9 @@ package coachbuf
 
 import (
 	"bytes"
+	"encoding"
+	"errors"
 	"fmt"
-	"log"
-	"math"
 	"reflect"
 
 	"github.com/trphume/coachbuf/internal/bitpacker"
@@ -14,119 +14,556 @@ import (
 // Decode takes in a value and deserializes it into the value v
 // argument v must be a non-nil pointer
 func Decode(data []byte, v any) error {
-	reader := bitpacker.NewReader(bytes.NewReader(data), len(data))
+	return DecodeOptions{}.Decode(data, v)
+}
+
+// DecodeOptions configures how data is validated when decoding, the zero value decodes like Decode
+type DecodeOptions struct {
+	// Fields selects the fields decoded, every other field is skipped, see DecodeFields
+	// the zero FieldMask selects every field
+	Fields FieldMask
+
+	// Mode selects what happens to the existing content of the value decoded into, DecodeOverwrite by default
+	Mode DecodeMode
+
+	// Positional reads the fields of every struct sorted by ordering number without their ordering numbers,
+	// as written by EncodeOptions.Positional
+	Positional bool
+
+	// Versioned reads the field count of every struct and the value header of every field, as written by
+	// EncodeOptions.Versioned, fields unknown to a struct are captured by its Unknown field or skipped
+	Versioned bool
+
+	// Fingerprint reads the schema fingerprint written by EncodeOptions.Fingerprint before the payload,
+	// ErrSchemaMismatch is returned when it differs from the fingerprint of the type decoded into
+	Fingerprint bool
+
+	// Strict rejects data that Encode never produces: a field appearing more than once or out of declaration order,
+	// an integer or compressed float whose bit pattern is outside [min, max], a varint or *big.Int not in its
+	// shortest form, padding bits that are not zero and whole words after the last field. In the versioned layout
+	// the value header of a known field must be the one Encode writes, fields unknown to the struct are not checked.
+	// Values read by a Marshaler, Serializer or blob are not checked either
+	Strict bool
+}
+
+// DecodeMode selects what happens to the existing content of the value decoded into
+type DecodeMode int
+
+const (
+	// DecodeOverwrite assigns every value read from data and keeps the content of fields absent from data,
+	// an absent Optional value is reset to None
+	DecodeOverwrite DecodeMode = iota
+
+	// DecodeReplace zeroes the value before decoding, so the result only holds what was read from data
+	DecodeReplace
+
+	// DecodeMerge applies data as a partial update: an absent Optional value keeps its existing value.
+	// A blob is always replaced whole, even when its Go type is a slice or a map like net.IP,
+	// since coachbuf has no slice or map fields there is nothing to append to or merge into
+	DecodeMerge
+)
+
+// Decode deserializes data into the value v with the options o
+// argument v must be a non-nil pointer
+func (o DecodeOptions) Decode(data []byte, v any) error {
 	pointerRv := reflect.ValueOf(v)
 	if pointerRv.Kind() != reflect.Pointer || pointerRv.IsNil() {
-		panic("argument v must be non-nil pointer type")
+		return fmt.Errorf("type=%v: %w", reflect.TypeOf(v), ErrInvalidDecodeTarget)
+	}
+	if len(data)%4 != 0 {
+		return fmt.Errorf("length=%d: %w", len(data), ErrInvalidDataLength)
 	}
 
-	return decodeValue(reader, pointerRv)
+	reader := bitpacker.NewReader(bytes.NewReader(data), len(data))
+	return decodeWith(reader, codecFor(pointerRv.Type().Elem()), pointerRv.Elem(), o)
 }
 
-func decodeValue(reader *bitpacker.Reader, pointerRv reflect.Value) error {
-	rv := reflect.Indirect(pointerRv)
-	switch rv.Kind() {
-	case reflect.Struct:
-		return decodeStruct(reader, rv)
-	case reflect.Int32:
-		if v, err := coachwire.ReadInteger(reader, math.MinInt32, math.MaxInt32); err != nil {
+// decodeWith reads into the settable rv with the compiled codec of its type
+func decodeWith(reader *bitpacker.Reader, tc *typeCodec, rv reflect.Value, opts DecodeOptions) error {
+	if tc.err != nil {
+		return tc.err
+	}
+
+	if opts.Positional && opts.Versioned {
+		return fmt.Errorf("positional and versioned modes can not be combined: %w", ErrInvalidOptions)
+	}
+
+	if opts.Fingerprint {
+		if err := checkFingerprint(reader, tc.fingerprint); err != nil {
+			return topLevelError(err, rv.Type())
+		}
+	}
+
+	d := &decodeState{reader: reader, strict: opts.Strict, merge: opts.Mode == DecodeMerge, positional: opts.Positional, versioned: opts.Versioned}
+	if opts.Fields.fields != nil {
+		selection, err := opts.Fields.resolve(tc.codec.info.plan)
+		if err != nil {
 			return err
+		}
+		d.selection = selection
+	}
+	if opts.Mode == DecodeReplace {
+		rv.Set(reflect.Zero(rv.Type()))
+	}
+
+	if err := tc.codec.decode(d, rv); err != nil {
+		return topLevelError(err, rv.Type())
+	}
+
+	if d.strict {
+		start := reader.NumBitsRead()
+		if err := checkPadding(reader); err != nil {
+			return topLevelError(&Error{Order: -1, BitOffset: start, Err: err}, rv.Type())
+		}
+	}
+
+	return nil
+}
+
+// checkFingerprint reads the schema fingerprint preceding the payload and compares it to want
+func checkFingerprint(reader *bitpacker.Reader, want uint32) error {
+	got, err := reader.Read(fingerprintBits)
+	if err != nil {
+		return fmt.Errorf("error reading fingerprint: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("fingerprint=%08x, want=%08x: %w", got, want, ErrSchemaMismatch)
+	}
+
+	return nil
+}
+
+// checkPadding returns an error when the bits left after the last field are not the zero padding written by Encode
+func checkPadding(reader *bitpacker.Reader) error {
+	remaining := reader.NumBitsRemaining()
+	if remaining >= 32 {
+		return fmt.Errorf("%d bits after the last field: %w", remaining, ErrTrailingData)
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	padding, err := reader.Read(remaining)
+	if err != nil {
+		return err
+	}
+	if padding != 0 {
+		return fmt.Errorf("padding=%b: %w", padding, ErrNonZeroPadding)
+	}
+
+	return nil
+}
+
+// decodeState holds the state of a single decode call
+type decodeState struct {
+	reader     *bitpacker.Reader
+	strict     bool            // see DecodeOptions.Strict
+	merge      bool            // see DecodeMerge
+	positional bool            // see DecodeOptions.Positional
+	versioned  bool            // see DecodeOptions.Versioned
+	selection  *fieldSelection // fields selected in the struct being decoded, nil selects every field
+	depth      int             // number of structs being decoded
+	allocated  int             // number of bytes allocated for blobs and *big.Int
+}
+
+// alloc accounts for n bytes allocated from decoded data, an error is returned once cbMaxDecodeAlloc is exceeded
+func (d *decodeState) alloc(n int) error {
+	d.allocated += n
+	if d.allocated > cbMaxDecodeAlloc {
+		return fmt.Errorf("allocated=%d bytes, limit=%d bytes: %w", d.allocated, cbMaxDecodeAlloc, ErrAllocLimitExceeded)
+	}
+
+	return nil
+}
+
+// structDecoder reads the fields of a struct in its layout, see decodeFields and decodeVersioned
+// the Validate method of the struct is called once every field is read
+func structDecoder(plan *structPlan) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		d.depth++
+		defer func() { d.depth-- }()
+		if d.depth > cbMaxDecodeDepth {
+			return fmt.Errorf("depth=%d: %w", d.depth, ErrMaxDepthExceeded)
+		}
+
+		selection := d.selection
+		defer func() { d.selection = selection }()
+
+		layout := plan.layout(d.positional, d.versioned)
+		if plan.unknown != noUnknownField && layout != layoutVersioned && d.depth > 1 {
+			return fmt.Errorf("type=%v: %w", plan.typ, ErrNestedUnknown)
+		}
+
+		var err error
+		if layout == layoutVersioned {
+			err = decodeVersioned(d, plan, rv, selection)
 		} else {
-			if !rv.CanSet() {
-				panic("cannot set value")
+			err = decodeFields(d, plan, layout, rv, selection)
+		}
+		if err != nil {
+			return err
+		}
+
+		// a struct decoded partially by DecodeFields is not validated
+		if plan.validator && selection == nil {
+			return validate(rv)
+		}
+
+		return nil
+	}
+}
+
+// decodeFields reads as many ordering numbers as there are tagged fields and the value following each of them
+// the Unknown field of a top level struct captures the bits following its fields
+func decodeFields(d *decodeState, plan *structPlan, layout structLayout, rv reflect.Value, selection *fieldSelection) error {
+	var seen [cbMaxOrderingNumber + 1]bool
+	for readCounter := 0; readCounter < len(plan.fields); readCounter++ {
+		field, start, err := readField(d, plan, layout, readCounter)
+		if err != nil {
+			return err
+		}
+		if err = checkDuplicate(d, plan, &seen, field.order, start); err != nil {
+			return err
+		}
+		// Encode writes the fields of the tagged layout in declaration order
+		if d.strict && field != &plan.fields[readCounter] {
+			return &Error{Order: int(field.order), BitOffset: start, Err: fmt.Errorf("order=%d of type=%v at position=%d: %w", field.order, plan.typ, readCounter, ErrFieldOrder)}
+		}
+
+		if selection != nil {
+			child, ok := selection.fields[field.order]
+			if !ok {
+				if err = field.codec.skip(d); err != nil {
+					return fieldError(err, field, start)
+				}
+				continue
 			}
-			rv.SetInt(int64(v))
-			return nil
+			d.selection = child
 		}
-	case reflect.Float32:
-		if v, err := coachwire.ReadFloat(reader); err != nil {
+
+		if err = decodeField(d, field, rv, start); err != nil {
 			return err
-		} else {
-			if !rv.CanSet() {
-				panic("cannot set value")
+		}
+	}
+
+	// only the top level value is followed by nothing but the fields unknown to it and padding, see structDecoder
+	if plan.unknown != noUnknownField {
+		return readUnknown(d, rv.Field(plan.unknown))
+	}
+
+	return nil
+}
+
+// checkDuplicate marks order as seen and returns an error in strict mode when it was seen already
+func checkDuplicate(d *decodeState, plan *structPlan, seen *[cbMaxOrderingNumber + 1]bool, order int32, start int) error {
+	if d.strict && seen[order] {
+		return &Error{Order: int(order), BitOffset: start, Err: fmt.Errorf("order=%d of type=%v: %w", order, plan.typ, ErrDuplicateField)}
+	}
+	seen[order] = true
+
+	return nil
+}
+
+// decodeField reads the value of field starting at bitOffset into the struct rv and checks its constraints
+func decodeField(d *decodeState, field *fieldPlan, rv reflect.Value, bitOffset int) error {
+	if err := field.codec.decode(d, rv.Field(field.index)); err != nil {
+		return fieldError(err, field, bitOffset)
+	}
+	if field.check != nil {
+		if err := field.check(rv.Field(field.index)); err != nil {
+			return fieldError(err, field, bitOffset)
+		}
+	}
+
+	return nil
+}
+
+// readField reads an ordering number and returns the field of plan it belongs to and the bit offset it starts at
+// in positional layout nothing is read and the field with the given position in ordering number order is returned
+func readField(d *decodeState, plan *structPlan, layout structLayout, position int) (*fieldPlan, int, error) {
+	start := d.reader.NumBitsRead()
+	if layout == layoutPositional {
+		return &plan.fields[plan.byOrder[position]], start, nil
+	}
+
+	order, err := coachwire.ReadInteger(d.reader, cbMinOrderingNumber, cbMaxOrderingNumber)
+	if err != nil {
+		return nil, start, &Error{Order: -1, BitOffset: start, Err: fmt.Errorf("error reading ordering number: %w", err)}
+	}
+
+	fieldNumber := plan.orderToField[order]
+	if fieldNumber == 0 {
+		return nil, start, &Error{Order: int(order), BitOffset: start, Err: fmt.Errorf("order=%d is not used by type=%v: %w", order, plan.typ, ErrUnknownOrdering)}
+	}
+
+	return &plan.fields[fieldNumber-1], start, nil
+}
+
+// int32Decoder reads an int32 in the range [min, max]
+func int32Decoder(min, max int32) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		read := coachwire.ReadInteger
+		if d.strict {
+			read = coachwire.ReadIntegerStrict
+		}
+
+		v, err := read(d.reader, min, max)
+		if err != nil {
+			return strictError(err)
+		}
+
+		return setInt(rv, int64(v))
+	}
+}
+
+func varIntegerDecoder(d *decodeState, rv reflect.Value) error {
+	read := coachwire.ReadVarInteger
+	if d.strict {
+		read = coachwire.ReadVarIntegerStrict
+	}
+
+	v, err := read(d.reader)
+	if err != nil {
+		return strictError(err)
+	}
+
+	return setInt(rv, int64(v))
+}
+
+func gammaIntegerDecoder(d *decodeState, rv reflect.Value) error {
+	v, err := coachwire.ReadGammaInteger(d.reader)
+	if err != nil {
+		return err
+	}
+
+	return setInt(rv, int64(v))
+}
+
+func float32Decoder(d *decodeState, rv reflect.Value) error {
+	v, err := coachwire.ReadFloat(d.reader)
+	if err != nil {
+		return err
+	}
+	if err = checkSettable(rv); err != nil {
+		return err
+	}
+	rv.SetFloat(float64(v))
+
+	return nil
+}
+
+// complexDecoder reads the real and then the imaginary part of a complex number written by complexEncoder
+func complexDecoder(part complexPart) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		var parts [2]float64
+		for i := range parts {
+			var err error
+			switch {
+			case part.compressed && d.strict:
+				var v float32
+				v, err = coachwire.ReadCompressedFloatStrict(d.reader, part.min, part.max, part.res)
+				parts[i] = float64(v)
+			case part.compressed:
+				var v float32
+				v, err = coachwire.ReadCompressedFloat(d.reader, part.min, part.max, part.res)
+				parts[i] = float64(v)
+			case part.kind == reflect.Complex64:
+				var v float32
+				v, err = coachwire.ReadFloat(d.reader)
+				parts[i] = float64(v)
+			default:
+				parts[i], err = coachwire.ReadFloat64(d.reader)
+			}
+			if err != nil {
+				return strictError(err)
 			}
-			rv.SetFloat(float64(v))
-			return nil
 		}
-	default:
-		return fmt.Errorf("decode type=%v: %w", rv.Type(), ErrUnsupportedType)
+		if err := checkSettable(rv); err != nil {
+			return err
+		}
+		rv.SetComplex(complex(parts[0], parts[1]))
+
+		return nil
 	}
 }
 
-func decodeStruct(reader *bitpacker.Reader, rv reflect.Value) error {
-	type orderingToFieldValue struct {
-		fieldNumber  int
-		cbStructTags []string
+func addrDecoder(d *decodeState, rv reflect.Value) error {
+	v, err := coachwire.ReadAddr(d.reader)
+	if err != nil {
+		return err
 	}
 
-	rt := rv.Type()
+	return setValue(rv, reflect.ValueOf(v))
+}
+
+func addrPortDecoder(d *decodeState, rv reflect.Value) error {
+	v, err := coachwire.ReadAddrPort(d.reader)
+	if err != nil {
+		return err
+	}
 
-	orderingToFieldMap := make(map[int32]orderingToFieldValue)
-	for i := 0; i < rt.NumField(); i++ {
-		structField := rt.Field(i)
-		structTag := structField.Tag.Get(cbStructTagsKey)
+	return setValue(rv, reflect.ValueOf(v))
+}
 
-		cbStructTags, order, err := getCoachbufTag(structField.Name, structTag)
+// bigIntDecoder reads a *big.Int whose absolute value fits in maxBits bits
+func bigIntDecoder(maxBits int) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		negative, bitLen, err := coachwire.ReadBigIntHeader(d.reader, maxBits)
 		if err != nil {
 			return err
 		}
+		// the magnitude is charged before it is allocated
+		if err = d.alloc((bitLen + 7) / 8); err != nil {
+			return err
+		}
 
-		if len(cbStructTags) < 1 {
-			continue
+		read := coachwire.ReadBigIntMagnitude
+		if d.strict {
+			read = coachwire.ReadBigIntMagnitudeStrict
 		}
 
-		if _, exist := orderingToFieldMap[order]; exist {
-			return fmt.Errorf("field=%s: %w", structField.Name, ErrDuplicateOrdering)
+		v, err := read(d.reader, negative, bitLen)
+		if err != nil {
+			return strictError(err)
 		}
 
-		orderingToFieldMap[order] = orderingToFieldValue{fieldNumber: i, cbStructTags: cbStructTags}
+		return setValue(rv, reflect.ValueOf(v))
 	}
+}
 
-	// start reading in the ordering number from the reader then find how to read via the map previously built
-	var readCounter int
-	for readCounter < len(orderingToFieldMap) {
-		order, err := coachwire.ReadInteger(reader, cbMinOrderingNumber, cbMaxOrderingNumber)
+// optionalDecoder reads the presence bit of an Optional and its value when the value is present
+// an absent value resets the Optional to its zero value unless decoding with DecodeMerge
+func optionalDecoder(inner decoderFunc) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		present, err := d.reader.Read(1)
 		if err != nil {
-			return fmt.Errorf("error reading ordering number: %w", err)
+			return err
+		}
+		if err = checkSettable(rv); err != nil {
+			return err
 		}
 
-		otmValue := orderingToFieldMap[order]
-		fieldValue := rv.Field(otmValue.fieldNumber)
-		switch fieldValue.Kind() {
-		case reflect.Struct:
-			err = decodeStruct(reader, fieldValue)
-		case reflect.Int32:
-			var min, max, v int32
-			min, max, err = getMinAndMaxTags(otmValue.cbStructTags)
-			if err != nil {
-				break
+		if present == 0 {
+			if d.merge {
+				return nil
 			}
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
 
-			v, err = coachwire.ReadInteger(reader, min, max)
-			log.Println("Hellllooooo", v)
-			if err == nil {
-				if !fieldValue.CanSet() {
-					panic("cannot set value")
-				}
-				fieldValue.SetInt(int64(v))
-			}
-		case reflect.Float32:
-			var v float32
-			v, err = coachwire.ReadFloat(reader)
-			if err == nil {
-				if !fieldValue.CanSet() {
-					panic("cannot set value")
-				}
-				fieldValue.SetFloat(float64(v))
-			}
-		default:
-			err = fmt.Errorf("encode type=%v: %w", fieldValue.Type(), ErrUnsupportedType)
+		rv.Field(optionalValidField).SetBool(true)
+		return inner(d, rv.Field(optionalValueField))
+	}
+}
+
+// unmarshalerDecoder calls the UnmarshalCoachbuf method of the value
+func unmarshalerDecoder(tag Tag) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		u, err := decodeReceiver(rv, unmarshalerType)
+		if err != nil {
+			return err
 		}
+
+		return u.(Unmarshaler).UnmarshalCoachbuf(BitReader{r: d.reader}, tag)
+	}
+}
+
+// serializerDecoder calls the SerializeCoachbuf method of the value with a reading Stream
+func serializerDecoder(tag Tag) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		s, err := decodeReceiver(rv, serializerType)
 		if err != nil {
-			return fmt.Errorf("field=%s: %w", rt.Field(otmValue.fieldNumber).Name, err)
+			return err
 		}
 
-		readCounter++
+		return s.(Serializer).SerializeCoachbuf(coachwire.NewReadStream(d.reader), tag)
+	}
+}
+
+// blobDecoder reads the bytes written by blobEncoder and passes them to the UnmarshalBinary or UnmarshalText method of the value
+func blobDecoder(text bool, maxLen int) decoderFunc {
+	return func(d *decodeState, rv reflect.Value) error {
+		data, err := coachwire.ReadBytes(d.reader, maxLen)
+		if err != nil {
+			return err
+		}
+		if err = d.alloc(len(data)); err != nil {
+			return err
+		}
+
+		return unmarshalBlob(rv, data, text)
+	}
+}
+
+// unmarshalBlob passes data to the UnmarshalBinary or UnmarshalText method of the value
+func unmarshalBlob(rv reflect.Value, data []byte, text bool) error {
+	if text {
+		u, err := decodeReceiver(rv, textUnmarshalerType)
+		if err != nil {
+			return err
+		}
+		return u.(encoding.TextUnmarshaler).UnmarshalText(data)
+	}
+
+	u, err := decodeReceiver(rv, binaryUnmarshalerType)
+	if err != nil {
+		return err
+	}
+	return u.(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+}
+
+// decodeReceiver returns the value, or a pointer to it, implementing the interface type iface
+// a nil pointer implementing iface is allocated first
+func decodeReceiver(rv reflect.Value, iface reflect.Type) (any, error) {
+	if rv.Kind() == reflect.Pointer && rv.Type().Implements(iface) {
+		if rv.IsNil() {
+			if err := setValue(rv, reflect.New(rv.Type().Elem())); err != nil {
+				return nil, err
+			}
+		}
+		return rv.Interface(), nil
+	}
+
+	return rv.Addr().Interface(), nil
+}
+
+// strictError reports a value rejected by a strict read of coachwire as ErrValueOutOfRange or ErrNonCanonical
+func strictError(err error) error {
+	if errors.Is(err, coachwire.ErrOutOfRange) {
+		return fmt.Errorf("%w: %w", ErrValueOutOfRange, err)
+	}
+	if errors.Is(err, coachwire.ErrNonCanonical) {
+		return fmt.Errorf("%w: %w", ErrNonCanonical, err)
+	}
+
+	return err
+}
+
+// setInt assigns v to the settable integer fieldValue
+func setInt(fieldValue reflect.Value, v int64) error {
+	if err := checkSettable(fieldValue); err != nil {
+		return err
+	}
+	fieldValue.SetInt(v)
+
+	return nil
+}
+
+// setValue assigns v to the settable fieldValue
+func setValue(fieldValue, v reflect.Value) error {
+	if err := checkSettable(fieldValue); err != nil {
+		return err
+	}
+	fieldValue.Set(v)
+
+	return nil
+}
+
+// checkSettable returns an error when rv can not be assigned
+// compileStruct rejects unexported fields so this only guards against a bug in coachbuf
+func checkSettable(rv reflect.Value) error {
+	if !rv.CanSet() {
+		return fmt.Errorf("cannot set value of type=%v: %w", rv.Type(), ErrUnsupportedType)
 	}
 
 	return nil
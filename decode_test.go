@@ -2,6 +2,10 @@ package coachbuf_test
 
 import (
 	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"runtime"
 	"testing"
 
 	"github.com/trphume/coachbuf"
@@ -49,25 +53,31 @@ func TestDecode(t *testing.T) {
 
 	t.Run("non pointer v argument", func(t *testing.T) {
 		t.Parallel()
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Deode() expect panic for bad v argument")
-			}
-		}()
 
 		inputDecode := int32(100)
-		_ = coachbuf.Decode([]byte{255, 255, 255, 255}, inputDecode)
+		if err := coachbuf.Decode([]byte{255, 255, 255, 255}, inputDecode); !errors.Is(err, coachbuf.ErrInvalidDecodeTarget) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrInvalidDecodeTarget)
+		}
 	})
 
 	t.Run("nil pointer v argument", func(t *testing.T) {
 		t.Parallel()
-		defer func() {
-			if r := recover(); r == nil {
-				t.Errorf("Deode() expect panic for bad v argument")
-			}
-		}()
 
-		_ = coachbuf.Decode([]byte{255, 255, 255, 255}, nil)
+		if err := coachbuf.Decode([]byte{255, 255, 255, 255}, nil); !errors.Is(err, coachbuf.ErrInvalidDecodeTarget) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrInvalidDecodeTarget)
+		}
+		if err := coachbuf.Decode([]byte{255, 255, 255, 255}, (*int32)(nil)); !errors.Is(err, coachbuf.ErrInvalidDecodeTarget) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrInvalidDecodeTarget)
+		}
+	})
+
+	t.Run("data length not a multiple of 4 bytes", func(t *testing.T) {
+		t.Parallel()
+
+		var inputDecode int32
+		if err := coachbuf.Decode([]byte{255, 255, 255}, &inputDecode); !errors.Is(err, coachbuf.ErrInvalidDataLength) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrInvalidDataLength)
+		}
 	})
 
 	t.Run("Unsupported type", func(t *testing.T) {
@@ -85,3 +95,256 @@ func TestDecode(t *testing.T) {
 		}
 	})
 }
+
+// blobBytes is written as a blob through its encoding.BinaryMarshaler
+type blobBytes []byte
+
+func (b blobBytes) MarshalBinary() ([]byte, error) { return b, nil }
+
+func (b *blobBytes) UnmarshalBinary(data []byte) error {
+	*b = data
+	return nil
+}
+
+func TestDecodeMode(t *testing.T) {
+	t.Run("absent fields", func(t *testing.T) {
+		t.Parallel()
+
+		type Pair struct {
+			First  int32 `coachbuf:"1,min=0,max=100"`
+			Second int32 `coachbuf:"2,min=0,max=100"`
+		}
+
+		// the first field is written twice so the second field is absent
+		w := coachbuf.NewBitWriter()
+		for _, v := range []int32{5, 6} {
+			if err := w.WriteInteger(1, 0, 255); err != nil {
+				t.Fatalf("WriteInteger() = %v, want %v", err.Error(), nil)
+			}
+			if err := w.WriteInteger(v, 0, 100); err != nil {
+				t.Fatalf("WriteInteger() = %v, want %v", err.Error(), nil)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() = %v, want %v", err.Error(), nil)
+		}
+
+		tests := []struct {
+			mode coachbuf.DecodeMode
+			want Pair
+		}{
+			{mode: coachbuf.DecodeOverwrite, want: Pair{First: 6, Second: 7}},
+			{mode: coachbuf.DecodeReplace, want: Pair{First: 6, Second: 0}},
+			{mode: coachbuf.DecodeMerge, want: Pair{First: 6, Second: 7}},
+		}
+		for _, tt := range tests {
+			result := Pair{First: 3, Second: 7}
+			if err := (coachbuf.DecodeOptions{Mode: tt.mode}).Decode(w.Bytes(), &result); err != nil {
+				t.Errorf("DecodeOptions.Decode() mode=%d = %v, want %v", tt.mode, err, nil)
+			}
+			if result != tt.want {
+				t.Errorf("DecodeOptions.Decode() mode=%d = %v, want %v", tt.mode, result, tt.want)
+			}
+		}
+	})
+
+	t.Run("optional and blob values", func(t *testing.T) {
+		t.Parallel()
+
+		type Update struct {
+			Shield coachbuf.Optional[int32] `coachbuf:"1,min=0,max=10"`
+			Log    blobBytes                `coachbuf:"2,maxlen=16"`
+			IP     net.IP                   `coachbuf:"3,maxlen=64"`
+		}
+
+		data, err := coachbuf.Encode(Update{Log: blobBytes("b"), IP: net.ParseIP("10.0.0.2")})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		// blobs are replaced whole in every mode, merging the bytes of an address would corrupt it
+		tests := []struct {
+			mode coachbuf.DecodeMode
+			want Update
+		}{
+			{mode: coachbuf.DecodeOverwrite, want: Update{Log: blobBytes("b"), IP: net.ParseIP("10.0.0.2")}},
+			{mode: coachbuf.DecodeReplace, want: Update{Log: blobBytes("b"), IP: net.ParseIP("10.0.0.2")}},
+			{mode: coachbuf.DecodeMerge, want: Update{Shield: coachbuf.Some[int32](5), Log: blobBytes("b"), IP: net.ParseIP("10.0.0.2")}},
+		}
+		for _, tt := range tests {
+			result := Update{Shield: coachbuf.Some[int32](5), Log: blobBytes("a"), IP: net.ParseIP("10.0.0.1")}
+			if err := (coachbuf.DecodeOptions{Mode: tt.mode}).Decode(data, &result); err != nil {
+				t.Errorf("DecodeOptions.Decode() mode=%d = %v, want %v", tt.mode, err, nil)
+			}
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("DecodeOptions.Decode() mode=%d = %v, want %v", tt.mode, result, tt.want)
+			}
+		}
+	})
+}
+
+func TestDecodeLimits(t *testing.T) {
+	// not parallel so that the allocations of other tests are not counted
+	t.Run("big.Int length beyond data", func(t *testing.T) {
+		type Huge struct {
+			B *big.Int `coachbuf:"1,maxbits=2147483647"`
+		}
+
+		// ordering 1, sign bit 0 and a 31 bits length of 2147483647 while only a few bits remain
+		data := []byte{0x01, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00}
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		if err := coachbuf.Decode(data, &Huge{}); err == nil {
+			t.Errorf("Decode() = %v, want an error", err)
+		}
+		runtime.ReadMemStats(&after)
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("Decode() allocated %d bytes, want at most %d", allocated, 1<<20)
+		}
+	})
+
+	t.Run("unknown ordering", func(t *testing.T) {
+		t.Parallel()
+
+		type Player struct {
+			Health int32 `coachbuf:"1,min=0,max=100"`
+		}
+
+		if err := coachbuf.Decode([]byte{2, 0, 0, 0}, &Player{}); !errors.Is(err, coachbuf.ErrUnknownOrdering) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrUnknownOrdering)
+		}
+	})
+
+	t.Run("nesting depth", func(t *testing.T) {
+		t.Parallel()
+
+		// every level holds the next one, the innermost level holds an int32
+		nested := func(depth int) reflect.Type {
+			rt := reflect.TypeOf(int32(0))
+			for i := 0; i < depth; i++ {
+				rt = reflect.StructOf([]reflect.StructField{{Name: "Next", Type: rt, Tag: `coachbuf:"1,min=0,max=1"`}})
+			}
+			return rt
+		}
+
+		for _, tt := range []struct {
+			depth int
+			err   error
+		}{
+			{depth: 64, err: nil},
+			{depth: 65, err: coachbuf.ErrMaxDepthExceeded},
+		} {
+			v := reflect.New(nested(tt.depth))
+			data, err := coachbuf.Encode(v.Elem().Interface())
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+			if err := coachbuf.Decode(data, v.Interface()); !errors.Is(err, tt.err) {
+				t.Errorf("Decode() depth=%d = %v, want %v", tt.depth, err, tt.err)
+			}
+		}
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		t.Parallel()
+
+		type Blobs struct {
+			First  blobBytes `coachbuf:"1,maxlen=600000"`
+			Second blobBytes `coachbuf:"2,maxlen=600000"`
+		}
+
+		data, err := coachbuf.Encode(Blobs{First: make(blobBytes, 600000), Second: make(blobBytes, 600000)})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if err := coachbuf.Decode(data, &Blobs{}); !errors.Is(err, coachbuf.ErrAllocLimitExceeded) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrAllocLimitExceeded)
+		}
+	})
+
+	t.Run("blob length beyond data", func(t *testing.T) {
+		t.Parallel()
+
+		type Blob struct {
+			Data blobBytes `coachbuf:"1,maxlen=1000000"`
+		}
+
+		// ordering 1 followed by a length of 1000000 bytes while only a few bits remain
+		w := coachbuf.NewBitWriter()
+		if err := w.WriteInteger(1, 0, 255); err != nil {
+			t.Fatalf("WriteInteger() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.WriteInteger(1000000, 0, 1000000); err != nil {
+			t.Fatalf("WriteInteger() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Blob
+		if err := coachbuf.Decode(w.Bytes(), &result); err == nil {
+			t.Errorf("Decode() = %v, want an error", err)
+		}
+	})
+
+}
+
+func TestDecodeStrict(t *testing.T) {
+	type Player struct {
+		Health int32     `coachbuf:"1,min=0,max=100"`
+		Speed  complex64 `coachbuf:"2,min=0,max=10,res=1"`
+		Mana   int32     `coachbuf:"3,enc=varint"`
+	}
+
+	// payload writes every value with its number of bits, the ordering number takes 8 bits,
+	// Health 7 bits, each part of Speed 4 bits and each group of Mana 5 bits
+	payload := func(values ...[2]uint32) []byte {
+		w := coachbuf.NewBitWriter()
+		for _, v := range values {
+			if err := w.WriteBits(v[0], int(v[1])); err != nil {
+				t.Fatalf("WriteBits() = %v, want %v", err.Error(), nil)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() = %v, want %v", err.Error(), nil)
+		}
+		return w.Bytes()
+	}
+
+	valid, err := coachbuf.Encode(Player{Health: 50, Speed: complex(3, 4)})
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "valid", data: valid, err: nil},
+		{name: "field appears twice", data: payload([2]uint32{1, 8}, [2]uint32{50, 7}, [2]uint32{1, 8}, [2]uint32{60, 7}, [2]uint32{2, 8}, [2]uint32{0, 4}, [2]uint32{0, 4}), err: coachbuf.ErrDuplicateField},
+		{name: "fields out of order", data: payload([2]uint32{2, 8}, [2]uint32{3, 4}, [2]uint32{4, 4}, [2]uint32{1, 8}, [2]uint32{50, 7}, [2]uint32{3, 8}, [2]uint32{0, 5}), err: coachbuf.ErrFieldOrder},
+		{name: "integer above max", data: payload([2]uint32{1, 8}, [2]uint32{101, 7}, [2]uint32{2, 8}, [2]uint32{0, 4}, [2]uint32{0, 4}, [2]uint32{3, 8}, [2]uint32{0, 5}), err: coachbuf.ErrValueOutOfRange},
+		{name: "compressed float above max", data: payload([2]uint32{1, 8}, [2]uint32{0, 7}, [2]uint32{2, 8}, [2]uint32{11, 4}, [2]uint32{0, 4}, [2]uint32{3, 8}, [2]uint32{0, 5}), err: coachbuf.ErrValueOutOfRange},
+		// a group of 0 with a continuation bit followed by a group of 0
+		{name: "non-canonical varint", data: payload([2]uint32{1, 8}, [2]uint32{50, 7}, [2]uint32{2, 8}, [2]uint32{3, 4}, [2]uint32{4, 4}, [2]uint32{3, 8}, [2]uint32{16, 5}, [2]uint32{0, 5}), err: coachbuf.ErrNonCanonical},
+		{name: "non-zero padding", data: payload([2]uint32{1, 8}, [2]uint32{50, 7}, [2]uint32{2, 8}, [2]uint32{3, 4}, [2]uint32{4, 4}, [2]uint32{3, 8}, [2]uint32{0, 5}, [2]uint32{1, 1}), err: coachbuf.ErrNonZeroPadding},
+		{name: "trailing word", data: append(append([]byte{}, valid...), 0, 0, 0, 0), err: coachbuf.ErrTrailingData},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// every payload is accepted when not decoding in strict mode
+			if err := coachbuf.Decode(tt.data, &Player{}); err != nil {
+				t.Errorf("Decode() = %v, want %v", err, nil)
+			}
+
+			if err := (coachbuf.DecodeOptions{Strict: true}).Decode(tt.data, &Player{}); !errors.Is(err, tt.err) {
+				t.Errorf("DecodeOptions.Decode() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -1,8 +1,12 @@
 package coachbuf
 
 import (
+	"bytes"
+	"encoding"
+	"errors"
 	"fmt"
-	"math"
+	"math/big"
+	"net/netip"
 	"reflect"
 
 	"github.com/trphume/coachbuf/internal/bitpacker"
@@ -11,78 +15,309 @@ import (
 
 // Encode takes in a value and serializes it into a slice of byte in Coachbuf format
 func Encode(v any) ([]byte, error) {
+	return EncodeOptions{}.Encode(v)
+}
+
+// EncodeOptions configures the wire format written when encoding, the zero value encodes like Encode
+type EncodeOptions struct {
+	// Positional writes the fields of every struct sorted by ordering number without their ordering numbers,
+	// the data must be decoded with DecodeOptions.Positional and the exact same types
+	Positional bool
+
+	// Versioned writes the number of fields of every struct and a header before every value holding its width or
+	// its length in bits, so that a decoder with DecodeOptions.Versioned skips the fields it does not know.
+	// Fields may be added and removed, but the type of a field must not change. Can not be combined with Positional
+	Versioned bool
+
+	// Fingerprint writes a 32 bit hash of the schema of the type before the payload, computed from the ordering
+	// numbers, encodings and tags of its fields, the data must be decoded with DecodeOptions.Fingerprint
+	Fingerprint bool
+}
+
+// Encode serializes v into a slice of byte in Coachbuf format with the options o
+func (o EncodeOptions) Encode(v any) ([]byte, error) {
 	writer := bitpacker.NewWriter()
-	rv := reflect.ValueOf(v)
-	if err := encodeValue(writer, rv); err != nil {
+	if err := encode(writer, v, o); err != nil {
 		return nil, err
 	}
 
-	if err := writer.FlushBits(); err != nil {
-		return nil, fmt.Errorf("could not FlushBits() after writing: %w", ErrWriterInvalidState)
+	return writer.Bytes(), nil
+}
+
+// Append serializes v in Coachbuf format and appends it to dst, returning the extended slice
+// the capacity of dst is reused when it is large enough to hold the payload
+func Append(dst []byte, v any) ([]byte, error) {
+	writer := bitpacker.NewWriterWithBuffer(bytes.NewBuffer(dst))
+	if err := encode(writer, v, EncodeOptions{}); err != nil {
+		return dst, err
 	}
 
 	return writer.Bytes(), nil
 }
 
-func encodeValue(writer *bitpacker.Writer, rv reflect.Value) error {
-	switch rv.Kind() {
-	case reflect.Struct:
-		return encodeStruct(writer, rv)
-	case reflect.Int32:
-		return coachwire.WriteInteger(writer, int32(rv.Int()), math.MinInt32, math.MaxInt32)
-	case reflect.Float32:
-		return coachwire.WriteFloat(writer, float32(rv.Float()))
-	default:
-		return fmt.Errorf("encode type=%v: %w", rv.Type(), ErrUnsupportedType)
+// EncodeInto serializes v in Coachbuf format into buf and returns the number of bytes written
+// ErrBufferFull is returned when the payload does not fit in buf, in which case the content of buf is unspecified
+func EncodeInto(buf []byte, v any) (int, error) {
+	writer := bitpacker.NewWriterWithLimit(bytes.NewBuffer(buf[:0]), len(buf))
+	if err := encode(writer, v, EncodeOptions{}); err != nil {
+		if errors.Is(err, bitpacker.ErrBufferFull) {
+			return 0, fmt.Errorf("payload exceeds buffer of %d bytes: %w", len(buf), ErrBufferFull)
+		}
+		return 0, err
 	}
+
+	return len(writer.Bytes()), nil
 }
 
-func encodeStruct(writer *bitpacker.Writer, rv reflect.Value) error {
-	rt := rv.Type()
+// encodeState holds the state of a single encode call
+type encodeState struct {
+	writer     *bitpacker.Writer
+	positional bool // see EncodeOptions.Positional
+	versioned  bool // see EncodeOptions.Versioned
+	depth      int  // number of structs being encoded
+}
 
-	orderExistMap := make(map[int]struct{}) // is needed to keep track of ordering number (to avoid duplicate)
-	for i := 0; i < rt.NumField(); i++ {
-		structField := rt.Field(i)
-		structTag := structField.Tag.Get(cbStructTagsKey)
+// encode writes the value v with its compiled codec and flushes the writer
+func encode(writer *bitpacker.Writer, v any, opts EncodeOptions) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return fmt.Errorf("encode nil value: %w", ErrUnsupportedType)
+	}
 
-		cbStructTags, order, err := getCoachbufTag(structField.Name, structTag)
-		if err != nil {
+	return encodeWith(writer, codecFor(rv.Type()), rv, opts)
+}
+
+// encodeWith writes rv with the compiled codec of its type and flushes the writer
+func encodeWith(writer *bitpacker.Writer, tc *typeCodec, rv reflect.Value, opts EncodeOptions) error {
+	if tc.err != nil {
+		return tc.err
+	}
+
+	if opts.Positional && opts.Versioned {
+		return fmt.Errorf("positional and versioned modes can not be combined: %w", ErrInvalidOptions)
+	}
+
+	if opts.Fingerprint {
+		if err := writer.Write(tc.fingerprint, fingerprintBits); err != nil {
 			return err
 		}
+	}
 
-		if len(cbStructTags) < 1 {
-			continue
+	e := &encodeState{writer: writer, positional: opts.Positional, versioned: opts.Versioned}
+	if err := tc.codec.encode(e, rv); err != nil {
+		return topLevelError(err, rv.Type())
+	}
+
+	if err := writer.FlushBits(); err != nil {
+		if errors.Is(err, bitpacker.ErrBufferFull) {
+			return err
 		}
-		if _, exist := orderExistMap[int(order)]; exist {
-			return fmt.Errorf("field=%s: %w", structField.Name, ErrDuplicateOrdering)
+		return fmt.Errorf("could not FlushBits() after writing: %w", ErrWriterInvalidState)
+	}
+
+	return nil
+}
+
+// structEncoder writes the ordering number followed by the value of every tagged field
+// and then the raw bits of the Unknown field of the struct
+// in positional mode only the values are written, sorted by ordering number
+// in versioned mode the fields are preceded by their count and every value by its value header
+func structEncoder(plan *structPlan) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		e.depth++
+		defer func() { e.depth-- }()
+
+		layout := plan.layout(e.positional, e.versioned)
+		if plan.unknown != noUnknownField && layout != layoutVersioned && e.depth > 1 {
+			return fmt.Errorf("type=%v: %w", plan.typ, ErrNestedUnknown)
 		}
-		orderExistMap[int(order)] = struct{}{}
 
-		if err = coachwire.WriteInteger(writer, order, cbMinOrderingNumber, cbMaxOrderingNumber); err != nil {
-			return fmt.Errorf("field=%s: %w", structField.Name, err)
+		var unknown Unknown
+		if plan.unknown != noUnknownField {
+			unknown = rv.Field(plan.unknown).Interface().(Unknown)
 		}
+		if layout == layoutVersioned {
+			count := int32(len(plan.fields) + unknown.fields)
+			if err := coachwire.WriteInteger(e.writer, count, 0, cbMaxOrderingNumber+1); err != nil {
+				return err
+			}
+		}
+
+		for i := range plan.fields {
+			field := &plan.fields[i]
+			if layout == layoutPositional {
+				field = &plan.fields[plan.byOrder[i]]
+			}
+
+			start := e.writer.NumBitsWritten()
+			if field.check != nil {
+				if err := field.check(rv.Field(field.index)); err != nil {
+					return fieldError(err, field, start)
+				}
+			}
+			if layout != layoutPositional {
+				if err := coachwire.WriteInteger(e.writer, field.order, cbMinOrderingNumber, cbMaxOrderingNumber); err != nil {
+					return fieldError(err, field, start)
+				}
+			}
 
-		fieldValue := rv.Field(i)
-		switch fieldValue.Kind() {
-		case reflect.Struct:
-			err = encodeStruct(writer, fieldValue)
-		case reflect.Int32:
-			var min, max int32
-			min, max, err = getMinAndMaxTags(cbStructTags)
+			var err error
+			if layout == layoutVersioned {
+				err = writeVersionedValue(e, field.codec, rv.Field(field.index))
+			} else {
+				err = field.codec.encode(e, rv.Field(field.index))
+			}
 			if err != nil {
-				break
+				return fieldError(err, field, start)
 			}
-			err = coachwire.WriteInteger(writer, int32(fieldValue.Int()), min, max)
-		case reflect.Float32:
-			err = coachwire.WriteFloat(writer, float32(fieldValue.Float()))
-		default:
-			err = fmt.Errorf("encode type=%v: %w", fieldValue.Type(), ErrUnsupportedType)
 		}
+
+		if plan.unknown != noUnknownField {
+			return writeUnknown(e, unknown, layout)
+		}
+
+		return nil
+	}
+}
+
+// int32Encoder writes an int32 in the range [min, max]
+func int32Encoder(min, max int32) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		return coachwire.WriteInteger(e.writer, int32(rv.Int()), min, max)
+	}
+}
+
+func varIntegerEncoder(e *encodeState, rv reflect.Value) error {
+	return coachwire.WriteVarInteger(e.writer, int32(rv.Int()))
+}
+
+func gammaIntegerEncoder(e *encodeState, rv reflect.Value) error {
+	return coachwire.WriteGammaInteger(e.writer, int32(rv.Int()))
+}
+
+func float32Encoder(e *encodeState, rv reflect.Value) error {
+	return coachwire.WriteFloat(e.writer, float32(rv.Float()))
+}
+
+// complexEncoder writes the real and then the imaginary part of a complex number
+// both parts are compressed when float range tags are given, otherwise they are written with full precision
+func complexEncoder(part complexPart) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		value := rv.Complex()
+		for _, v := range [2]float64{real(value), imag(value)} {
+			var err error
+			switch {
+			case part.compressed:
+				err = coachwire.WriteCompressedFloat(e.writer, float32(v), part.min, part.max, part.res)
+			case part.kind == reflect.Complex64:
+				err = coachwire.WriteFloat(e.writer, float32(v))
+			default:
+				err = coachwire.WriteFloat64(e.writer, v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func addrEncoder(e *encodeState, rv reflect.Value) error {
+	return coachwire.WriteAddr(e.writer, rv.Interface().(netip.Addr))
+}
+
+func addrPortEncoder(e *encodeState, rv reflect.Value) error {
+	return coachwire.WriteAddrPort(e.writer, rv.Interface().(netip.AddrPort))
+}
+
+// bigIntEncoder writes a *big.Int whose absolute value fits in maxBits bits
+func bigIntEncoder(maxBits int) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		return coachwire.WriteBigInt(e.writer, rv.Interface().(*big.Int), maxBits)
+	}
+}
+
+// optionalEncoder writes the presence bit of an Optional followed by its value when the value is present
+func optionalEncoder(inner encoderFunc) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		if !rv.Field(optionalValidField).Bool() {
+			return e.writer.Write(0, 1)
+		}
+		if err := e.writer.Write(1, 1); err != nil {
+			return err
+		}
+
+		return inner(e, rv.Field(optionalValueField))
+	}
+}
+
+// marshalerEncoder calls the MarshalCoachbuf method of the value
+func marshalerEncoder(tag Tag) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		m, err := encodeReceiver(rv, marshalerType)
 		if err != nil {
-			return fmt.Errorf("field=%s: %w", structField.Name, err)
+			return err
 		}
 
+		return m.(Marshaler).MarshalCoachbuf(BitWriter{w: e.writer}, tag)
 	}
+}
 
-	return nil
+// serializerEncoder calls the SerializeCoachbuf method of the value with a writing Stream
+func serializerEncoder(tag Tag) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		s, err := encodeReceiver(rv, serializerType)
+		if err != nil {
+			return err
+		}
+
+		return s.(Serializer).SerializeCoachbuf(coachwire.NewWriteStream(e.writer), tag)
+	}
+}
+
+// blobEncoder writes the bytes produced by the MarshalBinary or MarshalText method of the value
+// as a length in the range [0, maxLen] followed by every byte
+func blobEncoder(text bool, maxLen int) encoderFunc {
+	return func(e *encodeState, rv reflect.Value) error {
+		var data []byte
+		if text {
+			m, err := encodeReceiver(rv, textMarshalerType)
+			if err != nil {
+				return err
+			}
+			if data, err = m.(encoding.TextMarshaler).MarshalText(); err != nil {
+				return err
+			}
+		} else {
+			m, err := encodeReceiver(rv, binaryMarshalerType)
+			if err != nil {
+				return err
+			}
+			if data, err = m.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+				return err
+			}
+		}
+
+		return coachwire.WriteBytes(e.writer, data, maxLen)
+	}
+}
+
+// encodeReceiver returns the value, or a pointer to it, implementing the interface type iface
+// a value that is not addressable is copied so that methods with a pointer receiver can be called
+func encodeReceiver(rv reflect.Value, iface reflect.Type) (any, error) {
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, fmt.Errorf("nil type=%v: %w", rv.Type(), ErrUnsupportedType)
+	}
+	if rv.Type().Implements(iface) {
+		return rv.Interface(), nil
+	}
+
+	if !rv.CanAddr() {
+		addressable := reflect.New(rv.Type()).Elem()
+		addressable.Set(rv)
+		rv = addressable
+	}
+
+	return rv.Addr().Interface(), nil
 }
@@ -41,3 +41,142 @@ func TestEncode(t *testing.T) {
 		}
 	})
 }
+
+func TestAppend(t *testing.T) {
+	t.Run("appends after existing content", func(t *testing.T) {
+		t.Parallel()
+
+		input := float32(100.456)
+		want, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		prefix := []byte{1, 2, 3}
+		result, err := coachbuf.Append(prefix, input)
+		if err != nil {
+			t.Errorf("Append() = %v, want %v", err.Error(), nil)
+		}
+		if string(result) != string(prefix)+string(want) {
+			t.Errorf("Append() = %v, want %v", result, append(prefix, want...))
+		}
+	})
+}
+
+func TestEncodeInto(t *testing.T) {
+	t.Run("payload fits in buffer", func(t *testing.T) {
+		t.Parallel()
+
+		input := int32(255)
+		want, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		buf := make([]byte, 8)
+		n, err := coachbuf.EncodeInto(buf, input)
+		if err != nil {
+			t.Errorf("EncodeInto() = %v, want %v", err.Error(), nil)
+		}
+		if string(buf[:n]) != string(want) {
+			t.Errorf("EncodeInto() = %v, want %v", buf[:n], want)
+		}
+	})
+
+	t.Run("payload exceeds buffer", func(t *testing.T) {
+		t.Parallel()
+
+		input := struct {
+			First  float32 `coachbuf:"1"`
+			Second float32 `coachbuf:"2"`
+		}{First: 1, Second: 2}
+		want := coachbuf.ErrBufferFull
+
+		_, err := coachbuf.EncodeInto(make([]byte, 4), input)
+		if !errors.Is(err, want) {
+			t.Errorf("EncodeInto() = %v, want %v", err, want)
+		}
+	})
+
+	t.Run("empty buffer", func(t *testing.T) {
+		t.Parallel()
+
+		// the spare capacity of buf is not part of the buffer, only its length is
+		for _, buf := range [][]byte{nil, make([]byte, 0, 16)} {
+			n, err := coachbuf.EncodeInto(buf, int32(1))
+			if n != 0 || !errors.Is(err, coachbuf.ErrBufferFull) {
+				t.Errorf("EncodeInto() = %v, %v, want %v, %v", n, err, 0, coachbuf.ErrBufferFull)
+			}
+		}
+	})
+}
+
+type positionalInner struct {
+	Y int32 `coachbuf:"2,min=0,max=3"`
+	X int32 `coachbuf:"1,min=0,max=3"`
+}
+
+type positionalOuter struct {
+	Inner positionalInner `coachbuf:"2"`
+	Level int32           `coachbuf:"1,min=0,max=100"`
+}
+
+// positionalPacked declares the positional option on the struct itself
+type positionalPacked struct {
+	_     struct{}        `coachbuf:"positional"`
+	Inner positionalInner `coachbuf:"2"`
+	Level int32           `coachbuf:"1,min=0,max=100"`
+}
+
+func TestPositional(t *testing.T) {
+	t.Run("per call", func(t *testing.T) {
+		t.Parallel()
+
+		input := positionalOuter{Inner: positionalInner{X: 1, Y: 2}, Level: 100}
+		data, err := coachbuf.EncodeOptions{Positional: true}.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		// Level, X and Y without any ordering number
+		want := []byte{100 | 1<<7, 2 << 1, 0, 0}
+		if string(data) != string(want) {
+			t.Errorf("Encode() = %v, want %v", data, want)
+		}
+
+		var result positionalOuter
+		if err = (coachbuf.DecodeOptions{Positional: true}).Decode(data, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err, nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("per struct", func(t *testing.T) {
+		t.Parallel()
+
+		input := positionalPacked{Inner: positionalInner{X: 3, Y: 1}, Level: 42}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result positionalPacked
+		if err = coachbuf.Decode(data, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err, nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+
+		// the nested struct keeps its ordering numbers, the size matches the worst case
+		size, err := coachbuf.SizeBits(input)
+		if err != nil || size != 7+2*(8+2) {
+			t.Errorf("SizeBits() = %v, %v, want %v", size, err, 7+2*(8+2))
+		}
+		if maxBits, err := coachbuf.MaxBits[positionalPacked](); err != nil || maxBits != size {
+			t.Errorf("MaxBits() = %v, %v, want %v", maxBits, err, size)
+		}
+	})
+}
@@ -1,6 +1,10 @@
 package coachbuf
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
 
 var (
 	// ErrUnsupportedType indicates that coachbuf has not implemented support for encoding the value of the type yet
@@ -15,7 +19,116 @@ var (
 	// ErrOutOfRangeOrdering indicates that the ordering number tag is not within the accepted min and max range
 	ErrOutOfRangeOrdering = errors.New("given ordering number is not within accepted range")
 
+	// ErrSizeBudgetExceeded indicates that the worst case size of a struct exceeds the maxbits option of the struct
+	ErrSizeBudgetExceeded = errors.New("worst case size exceeds budget")
+
+	// ErrUnknownOrdering indicates that decoded data holds an ordering number not used by the struct being decoded
+	ErrUnknownOrdering = errors.New("unknown ordering number")
+
+	// ErrMaxDepthExceeded indicates that decoded data nests structs deeper than coachbuf accepts
+	ErrMaxDepthExceeded = errors.New("maximum nesting depth exceeded")
+
+	// ErrAllocLimitExceeded indicates that decoded data allocates more bytes than coachbuf accepts for a single value
+	ErrAllocLimitExceeded = errors.New("allocation limit exceeded")
+
+	// ErrDuplicateField indicates that decoded data holds the same ordering number more than once, only returned in strict mode
+	ErrDuplicateField = errors.New("field appears more than once")
+
+	// ErrValueOutOfRange indicates that a decoded value is not within the min and max range of its field, only returned in strict mode
+	ErrValueOutOfRange = errors.New("decoded value out of range")
+
+	// ErrNonCanonical indicates that a decoded value is not written the way Encode writes it, only returned in strict mode
+	ErrNonCanonical = errors.New("non-canonical encoding")
+
+	// ErrFieldOrder indicates that decoded data holds fields in another order than Encode writes them, only returned in strict mode
+	ErrFieldOrder = errors.New("field out of order")
+
+	// ErrNonZeroPadding indicates that the padding bits after the last field of decoded data are not zero, only returned in strict mode
+	ErrNonZeroPadding = errors.New("non-zero padding bits")
+
+	// ErrTrailingData indicates that decoded data holds whole 32 bit words after the last field, only returned in strict mode
+	ErrTrailingData = errors.New("trailing data after last field")
+
+	// ErrFieldLength indicates that the length in the value header of a field in versioned mode does not match its value
+	ErrFieldLength = errors.New("field length mismatch")
+
+	// ErrNestedUnknown indicates that a struct with an Unknown field is encoded or decoded inside another value outside
+	// versioned mode, where only the trailing bits of the top level value can be captured
+	ErrNestedUnknown = errors.New("unknown field of a nested struct")
+
+	// ErrLayoutMismatch indicates that an Unknown field is encoded in a different layout than the one it was decoded from
+	ErrLayoutMismatch = errors.New("unknown fields layout mismatch")
+
+	// ErrSchemaMismatch indicates that the schema fingerprint preceding decoded data differs from the one of the type decoded into
+	ErrSchemaMismatch = errors.New("schema fingerprint mismatch")
+
+	// ErrInvalidOptions indicates that EncodeOptions or DecodeOptions combine options that exclude each other
+	ErrInvalidOptions = errors.New("invalid options")
+
+	// ErrInvalidDecodeTarget indicates that the value given to Decode is not a non-nil pointer
+	ErrInvalidDecodeTarget = errors.New("decode target must be a non-nil pointer")
+
+	// ErrInvalidDataLength indicates that the data given to Decode is not a whole number of 32 bit words
+	ErrInvalidDataLength = errors.New("data length must be a multiple of 4 bytes")
+
+	// ErrMessageTooLarge indicates that the length prefix of a message read by a Decoder exceeds its MaxMessageSize
+	ErrMessageTooLarge = errors.New("message too large")
+
+	// ErrBufferFull indicates that the encoded payload does not fit in the buffer given to EncodeInto
+	ErrBufferFull = errors.New("buffer full")
+
 	// ErrWriterInvalidState indicates that bitpacker.Writer is in an invalid state and could not continue the requested operation
 	// This implies that there is a bug in coachbuf
 	ErrWriterInvalidState = errors.New("invalid writer state")
 )
+
+// Error is returned when a value could not be encoded or decoded, it unwraps to the cause of the failure
+type Error struct {
+	// Path locates the innermost failing field starting from the name of the top level type, such as Player.Inventory.Count
+	Path string
+	// Order is the ordering number of the innermost failing field, -1 when the failure is not within a field
+	Order int
+	// BitOffset is the offset in bits from the start of the payload where the innermost failing field starts
+	BitOffset int
+	Err       error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("path=%s order=%d bit=%d: %v", e.Path, e.Order, e.BitOffset, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// fieldError locates err in the field starting at bitOffset, an *Error of a nested field is prefixed with the field name
+func fieldError(err error, field *fieldPlan, bitOffset int) error {
+	if e, ok := err.(*Error); ok { //nolint:errorlint // only errors of nested fields are prefixed
+		e.Path = joinPath(field.name, e.Path)
+		return e
+	}
+
+	return &Error{Path: field.name, Order: int(field.order), BitOffset: bitOffset, Err: err}
+}
+
+// topLevelError prefixes the path of err with the name of the top level type, other errors are wrapped in an *Error
+func topLevelError(err error, rt reflect.Type) error {
+	if e, ok := err.(*Error); ok { //nolint:errorlint // only errors of fields are prefixed
+		e.Path = joinPath(rt.Name(), e.Path)
+		return e
+	}
+
+	return &Error{Path: rt.Name(), Order: -1, Err: err}
+}
+
+// joinPath joins two parts of a field path with a dot, either part can be empty
+func joinPath(prefix, path string) string {
+	switch {
+	case prefix == "":
+		return path
+	case path == "":
+		return prefix
+	default:
+		return prefix + "." + path
+	}
+}
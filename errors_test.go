@@ -0,0 +1,134 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type errorItem struct {
+	Count int32 `coachbuf:"1,min=0,max=10"`
+}
+
+type errorPlayer struct {
+	Health    int32     `coachbuf:"1,min=0,max=100"`
+	Inventory errorItem `coachbuf:"2"`
+}
+
+func TestError(t *testing.T) {
+	valid, err := coachbuf.Encode(errorPlayer{Health: 50, Inventory: errorItem{Count: 3}})
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want coachbuf.Error
+	}{
+		{
+			name: "Encode nested field out of range",
+			run: func() error {
+				_, err := coachbuf.Encode(errorPlayer{Inventory: errorItem{Count: 11}})
+				return err
+			},
+			want: coachbuf.Error{Path: "errorPlayer.Inventory.Count", Order: 1, BitOffset: 8 + 7 + 8},
+		},
+		{
+			name: "Decode truncated nested field",
+			run: func() error {
+				return coachbuf.Decode(valid[:4], &errorPlayer{})
+			},
+			want: coachbuf.Error{Path: "errorPlayer.Inventory.Count", Order: 1, BitOffset: 8 + 7 + 8},
+		},
+		{
+			name: "Decode unknown ordering",
+			run: func() error {
+				return coachbuf.Decode([]byte{9, 0, 0, 0}, &errorPlayer{})
+			},
+			want: coachbuf.Error{Path: "errorPlayer", Order: 9, BitOffset: 0},
+		},
+		{
+			name: "Decode top level value",
+			run: func() error {
+				var v float32
+				return coachbuf.Decode([]byte{}, &v)
+			},
+			want: coachbuf.Error{Path: "float32", Order: -1, BitOffset: 0},
+		},
+		{
+			name: "Decode fingerprint mismatch",
+			run: func() error {
+				return coachbuf.DecodeOptions{Fingerprint: true}.Decode(valid, &errorPlayer{})
+			},
+			want: coachbuf.Error{Path: "errorPlayer", Order: -1, BitOffset: 0},
+		},
+		{
+			name: "Decode strict non-zero padding",
+			run: func() error {
+				padded := append([]byte(nil), valid...)
+				padded[len(padded)-1] |= 0x80
+				return coachbuf.DecodeOptions{Strict: true}.Decode(padded, &errorPlayer{})
+			},
+			want: coachbuf.Error{Path: "errorPlayer", Order: -1, BitOffset: 8 + 7 + 8 + 8 + 4},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var result *coachbuf.Error
+			if err := tt.run(); !errors.As(err, &result) {
+				t.Fatalf("error = %v, want %T", err, result)
+			}
+			if result.Path != tt.want.Path || result.Order != tt.want.Order || result.BitOffset != tt.want.BitOffset {
+				t.Errorf("error = %+v, want %+v", result, tt.want)
+			}
+			if result.Unwrap() == nil {
+				t.Errorf("Unwrap() = %v, want cause", nil)
+			}
+		})
+	}
+}
+
+func TestDecodeTargets(t *testing.T) {
+	type Unexported struct {
+		health int32 `coachbuf:"1,min=0,max=100"`
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		err  error
+	}{
+		{name: "unexported tagged field", run: func() error { return coachbuf.Decode([]byte{0, 0, 0, 0}, &Unexported{}) }, err: coachbuf.ErrUnsupportedType},
+		{name: "Codec nil pointer", run: func() error {
+			c, err := coachbuf.NewCodec[errorPlayer]()
+			if err != nil {
+				return err
+			}
+			return c.Decode([]byte{0, 0, 0, 0}, nil)
+		}, err: coachbuf.ErrInvalidDecodeTarget},
+		{name: "Codec data length", run: func() error {
+			c, err := coachbuf.NewCodec[errorPlayer]()
+			if err != nil {
+				return err
+			}
+			return c.Decode([]byte{0}, &errorPlayer{})
+		}, err: coachbuf.ErrInvalidDataLength},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.run(); !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package coachbuf
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fingerprintBits is the number of bits of the schema fingerprint preceding the payload, see EncodeOptions.Fingerprint
+const fingerprintBits = 32
+
+// fingerprint returns the FNV-1a hash of the schema of a type compiled to info: the layout of every struct and
+// the ordering number, encoding, kind and tags of every field, nested structs included.
+// Field names are left out so that renaming a field keeps the fingerprint
+func fingerprint(info codecInfo, rt reflect.Type) uint32 {
+	h := fnv.New32a()
+	writeSchema(h, info, rt)
+
+	return h.Sum32()
+}
+
+// writeSchema writes the canonical description of a value compiled to info. The kind of the value is written
+// since values of different kinds can share an encoding, float32 and complex64 for instance. The Go type is only
+// written for Marshaler and blob types since their wire format is not described by their tags
+func writeSchema(w io.Writer, info codecInfo, rt reflect.Type) {
+	fmt.Fprint(w, info.encoding)
+	if info.optional {
+		fmt.Fprint(w, "?")
+		rt = rt.Field(optionalValueField).Type
+	}
+
+	switch {
+	case info.plan != nil:
+		writeStructSchema(w, info.plan)
+	case info.encoding == EncodingCustom || info.encoding == EncodingBlob:
+		fmt.Fprintf(w, "(%v)", rt)
+	default:
+		fmt.Fprintf(w, "(%v)", rt.Kind())
+	}
+}
+
+// writeStructSchema writes the options of a struct followed by its fields sorted by ordering number,
+// tags are sorted so that their order in the struct tag does not matter
+func writeStructSchema(w io.Writer, plan *structPlan) {
+	fmt.Fprintf(w, "{positional=%t,versioned=%t", plan.opts.positional, plan.opts.versioned)
+	for _, i := range plan.byOrder {
+		field := &plan.fields[i]
+		tags := append([]string(nil), field.tags[1:]...)
+		sort.Strings(tags)
+
+		fmt.Fprintf(w, ";%d[%s]:", field.order, strings.Join(tags, ","))
+		writeSchema(w, field.codec.info, plan.typ.Field(field.index).Type)
+	}
+	fmt.Fprint(w, "}")
+}
@@ -0,0 +1,124 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type fingerprintStats struct {
+	Health int32 `coachbuf:"1,min=0,max=100"`
+}
+
+type fingerprintPlayer struct {
+	Level int32            `coachbuf:"1,min=0,max=10"`
+	Stats fingerprintStats `coachbuf:"2"`
+}
+
+func TestFingerprint(t *testing.T) {
+	input := fingerprintPlayer{Level: 7, Stats: fingerprintStats{Health: 55}}
+	data, err := coachbuf.EncodeOptions{Fingerprint: true}.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	t.Run("same schema", func(t *testing.T) {
+		t.Parallel()
+
+		var result fingerprintPlayer
+		if err := (coachbuf.DecodeOptions{Fingerprint: true}).Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+
+		// renaming fields and reordering tags does not change the wire format
+		type Renamed struct {
+			Rank  int32            `coachbuf:"1,max=10,min=0"`
+			Stats fingerprintStats `coachbuf:"2"`
+		}
+		var renamed Renamed
+		if err := (coachbuf.DecodeOptions{Fingerprint: true}).Decode(data, &renamed); err != nil {
+			t.Errorf("Decode() = %v, want %v", err, nil)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		type WiderRange struct {
+			Level int32            `coachbuf:"1,min=0,max=20"`
+			Stats fingerprintStats `coachbuf:"2"`
+		}
+		type NestedChange struct {
+			Level int32 `coachbuf:"1,min=0,max=10"`
+			Stats struct {
+				Health int32 `coachbuf:"1,enc=varint"`
+			} `coachbuf:"2"`
+		}
+		type OtherOrder struct {
+			Level int32            `coachbuf:"3,min=0,max=10"`
+			Stats fingerprintStats `coachbuf:"2"`
+		}
+
+		tests := []struct {
+			name   string
+			target any
+		}{
+			{name: "min and max", target: &WiderRange{}},
+			{name: "nested encoding", target: &NestedChange{}},
+			{name: "ordering number", target: &OtherOrder{}},
+		}
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				if err := (coachbuf.DecodeOptions{Fingerprint: true}).Decode(data, tt.target); !errors.Is(err, coachbuf.ErrSchemaMismatch) {
+					t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrSchemaMismatch)
+				}
+			})
+		}
+	})
+
+	t.Run("type changed with the same tags", func(t *testing.T) {
+		t.Parallel()
+
+		// both types are written with the float encoding
+		type Float struct {
+			Value float32 `coachbuf:"1"`
+		}
+		type Complex struct {
+			Value complex64 `coachbuf:"1"`
+		}
+
+		floatSchema, err := coachbuf.SchemaOf(Float{})
+		if err != nil {
+			t.Fatalf("SchemaOf() = %v, want %v", err, nil)
+		}
+		complexSchema, err := coachbuf.SchemaOf(Complex{})
+		if err != nil {
+			t.Fatalf("SchemaOf() = %v, want %v", err, nil)
+		}
+		if floatSchema.Fingerprint == complexSchema.Fingerprint {
+			t.Errorf("SchemaOf() Fingerprint = %08x for float32 and complex64, want different fingerprints", floatSchema.Fingerprint)
+		}
+	})
+
+	t.Run("SchemaOf", func(t *testing.T) {
+		t.Parallel()
+
+		schema, err := coachbuf.SchemaOf(input)
+		if err != nil {
+			t.Fatalf("SchemaOf() = %v, want %v", err, nil)
+		}
+
+		// the fingerprint is written as the first 32 bits of the payload
+		got := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+		if schema.Fingerprint != got {
+			t.Errorf("SchemaOf() Fingerprint = %08x, want %08x", schema.Fingerprint, got)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package coachbuf_test
+
+import (
+	"math/big"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type fuzzNested struct {
+	Ratio  float32   `coachbuf:"1"`
+	Speed  complex64 `coachbuf:"2,min=-10,max=10,res=0.01"`
+	Weight complex128
+}
+
+type fuzzTarget struct {
+	Health  int32                         `coachbuf:"1,min=0,max=100"`
+	Score   int32                         `coachbuf:"2,enc=varint"`
+	Delta   int32                         `coachbuf:"3,enc=gamma"`
+	Nested  fuzzNested                    `coachbuf:"4"`
+	Shield  coachbuf.Optional[fuzzNested] `coachbuf:"5"`
+	Addr    netip.Addr                    `coachbuf:"6"`
+	Peer    netip.AddrPort                `coachbuf:"7"`
+	Balance *big.Int                      `coachbuf:"8,maxbits=128"`
+	IP      net.IP                        `coachbuf:"9,maxlen=16"`
+	Huge    *big.Int                      `coachbuf:"10,maxbits=2147483647"`
+}
+
+// FuzzDecode decodes arbitrary data, Decode must return an error rather than panic for corrupted or hostile input
+func FuzzDecode(f *testing.F) {
+	seeds := []fuzzTarget{
+		{Addr: netip.MustParseAddr("10.0.0.1"), Peer: netip.MustParseAddrPort("[::1]:80")},
+		{
+			Health:  100,
+			Score:   -1 << 31,
+			Delta:   1<<31 - 1,
+			Nested:  fuzzNested{Ratio: 1.5, Speed: complex(-10, 10)},
+			Shield:  coachbuf.Some(fuzzNested{Ratio: -2}),
+			Addr:    netip.MustParseAddr("2001:db8::1"),
+			Peer:    netip.MustParseAddrPort("192.168.1.1:65535"),
+			Balance: new(big.Int).Lsh(big.NewInt(-1), 127),
+			IP:      net.ParseIP("::ffff:10.0.0.1"),
+		},
+	}
+	for _, seed := range seeds {
+		data, err := coachbuf.Encode(seed)
+		if err != nil {
+			f.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		f.Add(data)
+	}
+	versioned, err := coachbuf.EncodeOptions{Versioned: true}.Encode(seeds[len(seeds)-1])
+	if err != nil {
+		f.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+	f.Add(versioned)
+	f.Add([]byte{})
+	f.Add([]byte{255, 255, 255})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255})
+	// ordering 10 followed by a big.Int length far beyond the data
+	f.Add([]byte{10, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var result fuzzTarget
+		_ = coachbuf.Decode(data, &result)
+		_ = coachbuf.DecodeOptions{Strict: true}.Decode(data, &result)
+		_ = coachbuf.DecodeOptions{Versioned: true}.Decode(data, &result)
+		_ = coachbuf.DecodeOptions{Fingerprint: true}.Decode(data, &result)
+		_ = coachbuf.DecodeFields(data, &result, coachbuf.Fields(2, "Nested.Ratio"))
+	})
+}
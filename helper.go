@@ -62,3 +62,177 @@ func getMinAndMaxTags(tags []string) (int32, int32, error) {
 
 	return min, max, nil
 }
+
+// getEncodingTag is a helper function to find and retrieve the enc tag from a slice of string
+// an empty string is returned when the tag is not present, min and max tags can not be combined with enc
+func getEncodingTag(tags []string) (string, error) {
+	var enc string
+	var ranged bool
+	for _, tag := range tags {
+		switch {
+		case strings.HasPrefix(tag, "enc="):
+			enc = tag[4:]
+			if enc != cbEncodingVarint && enc != cbEncodingGamma {
+				return "", fmt.Errorf("enc tag value must be %s or %s, tag=%s: %w",
+					cbEncodingVarint, cbEncodingGamma, tag, ErrInvalidTagFormat)
+			}
+		case strings.HasPrefix(tag, "max=") || strings.HasPrefix(tag, "min="):
+			ranged = true
+		}
+	}
+
+	if enc != "" && ranged {
+		return "", fmt.Errorf("enc tag can not be combined with min and max tags, enc=%s: %w", enc, ErrInvalidTagFormat)
+	}
+
+	return enc, nil
+}
+
+// lookupTag is a helper function to find the value of a key=value tag from a slice of string
+// return the value and whether the key was found
+func lookupTag(tags []string, key string) (string, bool) {
+	prefix := key + "="
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, prefix) {
+			return tag[len(prefix):], true
+		}
+	}
+
+	return "", false
+}
+
+// getMaxBitsTag is a helper function to find and retrieve the required maxbits tag from a slice of string
+func getMaxBitsTag(tags []string) (int, error) {
+	value, ok := lookupTag(tags, "maxbits")
+	if !ok {
+		return 0, fmt.Errorf("maxbits tag is required: %w", ErrInvalidTagFormat)
+	}
+
+	maxBits, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || maxBits <= 0 {
+		return 0, fmt.Errorf("maxbits tag value must be a positive int32 number, tag=maxbits=%s: %w", value, ErrInvalidTagFormat)
+	}
+
+	return int(maxBits), nil
+}
+
+// getMaxLenTag is a helper function to find and retrieve the optional maxlen tag from a slice of string
+// cbDefaultMaxLen is returned when the tag is not given
+func getMaxLenTag(tags []string) (int, error) {
+	value, ok := lookupTag(tags, "maxlen")
+	if !ok {
+		return cbDefaultMaxLen, nil
+	}
+
+	maxLen, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || maxLen <= 0 {
+		return 0, fmt.Errorf("maxlen tag value must be a positive int32 number, tag=maxlen=%s: %w", value, ErrInvalidTagFormat)
+	}
+
+	return int(maxLen), nil
+}
+
+// getFloatRangeTags is a helper function to find and retrieve float min, max and res tags from a slice of string
+// return values min, max, res, whether the value should be compressed and err in this order
+// a value is only compressed when res is given in which case min and max are required
+func getFloatRangeTags(tags []string) (float32, float32, float32, bool, error) {
+	resValue, ok := lookupTag(tags, "res")
+	if !ok {
+		return 0, 0, 0, false, nil
+	}
+
+	var values [3]float32
+	for i, key := range [3]string{"min", "max", "res"} {
+		value, ok := lookupTag(tags, key)
+		if !ok {
+			return 0, 0, 0, false, fmt.Errorf("%s tag is required with res tag, res=%s: %w", key, resValue, ErrInvalidTagFormat)
+		}
+
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return 0, 0, 0, false, fmt.Errorf("%s tag value must be a float32 number, tag=%s=%s: %w", key, key, value, ErrInvalidTagFormat)
+		}
+		values[i] = float32(f)
+	}
+	if values[2] <= 0 {
+		return 0, 0, 0, false, fmt.Errorf("res tag value must be positive, res=%s: %w", resValue, ErrInvalidTagFormat)
+	}
+
+	return values[0], values[1], values[2], true, nil
+}
+
+// structOptions holds the options given in the coachbuf tag of a blank field of a struct, such as
+// _ struct{} `coachbuf:"maxbits=1200"`
+type structOptions struct {
+	maxBits    int  // budget of the worst case size in bits, zero when not given
+	positional bool // fields are written in ordering number order without ordering numbers
+	versioned  bool // fields are counted and carry a value header so that unknown fields can be skipped
+}
+
+// getStructOptions is a helper function to parse the options of a struct from the tag of a blank field
+func getStructOptions(structTag string) (structOptions, error) {
+	var opts structOptions
+	for _, tag := range strings.Split(structTag, ",") {
+		key, _, _ := strings.Cut(tag, "=")
+		switch key {
+		case "maxbits":
+			maxBits, err := getMaxBitsTag([]string{tag})
+			if err != nil {
+				return opts, err
+			}
+			opts.maxBits = maxBits
+		case "positional", "versioned":
+			if tag != key {
+				return opts, fmt.Errorf("%s option does not take a value, tag=%s: %w", key, tag, ErrInvalidTagFormat)
+			}
+			if key == "positional" {
+				opts.positional = true
+			} else {
+				opts.versioned = true
+			}
+		default:
+			return opts, fmt.Errorf("unknown struct option, tag=%s: %w", tag, ErrInvalidTagFormat)
+		}
+	}
+	if opts.positional && opts.versioned {
+		return opts, fmt.Errorf("positional and versioned options can not be combined, tag=%s: %w", structTag, ErrInvalidTagFormat)
+	}
+
+	return opts, nil
+}
+
+// fieldConstraints holds the constraint tags of a field checked when encoding and decoding
+type fieldConstraints struct {
+	nonZero bool
+	oneOf   []string // allowed values as written in the oneof tag, nil when not given
+	minLen  int      // minimum length, zero when not given
+}
+
+// getConstraintTags is a helper function to find and retrieve the nonzero, oneof and minlen tags from a slice of string
+// return the constraints, whether any of them was given and an error
+func getConstraintTags(tags []string) (fieldConstraints, bool, error) {
+	var c fieldConstraints
+	var found bool
+	for _, tag := range tags {
+		if tag == "nonzero" {
+			c.nonZero, found = true, true
+		}
+	}
+
+	if value, ok := lookupTag(tags, "oneof"); ok {
+		if value == "" {
+			return c, false, fmt.Errorf("oneof tag value missing, tag=oneof=: %w", ErrInvalidTagFormat)
+		}
+		c.oneOf, found = strings.Split(value, "|"), true
+	}
+
+	if value, ok := lookupTag(tags, "minlen"); ok {
+		minLen, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || minLen <= 0 {
+			return c, false, fmt.Errorf("minlen tag value must be a positive int32 number, tag=minlen=%s: %w", value, ErrInvalidTagFormat)
+		}
+		c.minLen, found = int(minLen), true
+	}
+
+	return c, found, nil
+}
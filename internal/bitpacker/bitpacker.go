@@ -17,6 +17,8 @@ type Writer struct {
 	buffer         *bytes.Buffer
 	numBitsWritten int
 	flushed        bool
+	limited        bool // the buffer never grows beyond limit bytes, see NewWriterWithLimit
+	limit          int
 }
 
 // NewWriter returns a Writer with an empty buffer
@@ -29,6 +31,17 @@ func NewWriterWithBuffer(b *bytes.Buffer) *Writer {
 	return &Writer{buffer: b}
 }
 
+// NewWriterWithLimit returns a Writer with a given buffer that never grows beyond limit bytes
+// writing a word that would exceed the limit returns ErrBufferFull
+func NewWriterWithLimit(b *bytes.Buffer, limit int) *Writer {
+	return &Writer{buffer: b, limited: true, limit: limit}
+}
+
+// NewMeasureWriter returns a Writer counting the bits written without storing them, Bytes always returns nil
+func NewMeasureWriter() *Writer {
+	return &Writer{}
+}
+
 // Write writes a binary value into the buffer given some desired number of bits to be written
 func (w *Writer) Write(value uint32, bits int) error {
 	if bits <= 0 || bits > 32 {
@@ -44,8 +57,8 @@ func (w *Writer) Write(value uint32, bits int) error {
 	w.scratchBits += bits
 
 	for w.scratchBits >= 32 {
-		if err := binary.Write(w.buffer, binary.LittleEndian, uint32(w.scratch&0xffffffff)); err != nil {
-			return fmt.Errorf("could not write scratch with value %b to buffer: %w", w.scratch, err)
+		if err := w.writeWord(); err != nil {
+			return err
 		}
 
 		w.wordIndex++
@@ -63,9 +76,8 @@ func (w *Writer) FlushBits() error {
 		return fmt.Errorf("FlushBits() previously called: %w", ErrMethodCallNotAllowed)
 	}
 	if w.scratchBits != 0 {
-		err := binary.Write(w.buffer, binary.LittleEndian, uint32(w.scratch&0xffffffff))
-		if err != nil {
-			return fmt.Errorf("could not write scratch with value %b to buffer: %w", w.scratch, err)
+		if err := w.writeWord(); err != nil {
+			return err
 		}
 
 		w.wordIndex++
@@ -77,8 +89,29 @@ func (w *Writer) FlushBits() error {
 	return nil
 }
 
+// writeWord writes the lowest 32 bits of scratch to the buffer in little endian byte order
+func (w *Writer) writeWord() error {
+	if w.buffer == nil {
+		return nil
+	}
+	if w.limited && w.buffer.Len()+4 > w.limit {
+		return fmt.Errorf("limit=%d bytes: %w", w.limit, ErrBufferFull)
+	}
+
+	var word [4]byte
+	binary.LittleEndian.PutUint32(word[:], uint32(w.scratch&0xffffffff))
+	if _, err := w.buffer.Write(word[:]); err != nil {
+		return fmt.Errorf("could not write scratch with value %b to buffer: %w", w.scratch, err)
+	}
+
+	return nil
+}
+
 // Bytes return a slice of bytes of value written to the buffer
 func (w *Writer) Bytes() []byte {
+	if w.buffer == nil {
+		return nil
+	}
 	return w.buffer.Bytes()
 }
 
@@ -95,16 +128,33 @@ type Reader struct {
 	reader      *bytes.Reader
 }
 
+// NewReader returns a Reader reading at most numBytes*8 bits from r
+// only whole 32 bit words of r can be read, trailing bytes that do not form a word are never read
 func NewReader(r *bytes.Reader, numBytes int) *Reader {
-	if r.Len()%4 != 0 {
-		panic("the reader needs to fit a 32 bit word")
+	totalBits := numBytes * 8
+	if wordBits := r.Len() / 4 * 32; totalBits > wordBits {
+		totalBits = wordBits
 	}
+	if totalBits < 0 {
+		totalBits = 0
+	}
+
 	return &Reader{
-		totalBits: numBytes * 8,
+		totalBits: totalBits,
 		reader:    r,
 	}
 }
 
+// NumBitsRead returns the number of bits read so far
+func (r *Reader) NumBitsRead() int {
+	return r.numBitsRead
+}
+
+// NumBitsRemaining returns the number of bits that can still be read
+func (r *Reader) NumBitsRemaining() int {
+	return r.totalBits - r.numBitsRead
+}
+
 func (r *Reader) Read(bits int) (uint32, error) {
 	if bits <= 0 || bits > 32 {
 		return 0, fmt.Errorf("bits should be in the range (0,32]: %w", ErrBitsInvalidRange)
@@ -128,6 +128,51 @@ func TestWriter(t *testing.T) {
 		}
 	})
 
+	t.Run("error when writing exceeds limit", func(t *testing.T) {
+		t.Parallel()
+
+		w := bitpacker.NewWriterWithLimit(new(bytes.Buffer), 4)
+		if err := w.Write(0, 32); err != nil {
+			t.Errorf("Write() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.Write(0, 1); err != nil {
+			t.Errorf("Write() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.FlushBits(); !errors.Is(err, bitpacker.ErrBufferFull) {
+			t.Errorf("FlushBits() = %v, want %v", err, bitpacker.ErrBufferFull)
+		}
+	})
+
+	t.Run("error when writing with a limit of zero", func(t *testing.T) {
+		t.Parallel()
+
+		w := bitpacker.NewWriterWithLimit(new(bytes.Buffer), 0)
+		if err := w.Write(0, 32); !errors.Is(err, bitpacker.ErrBufferFull) {
+			t.Errorf("Write() = %v, want %v", err, bitpacker.ErrBufferFull)
+		}
+	})
+
+	t.Run("successful when measuring without a buffer", func(t *testing.T) {
+		t.Parallel()
+
+		w := bitpacker.NewMeasureWriter()
+		for i := 0; i < 3; i++ {
+			if err := w.Write(0b101, 30); err != nil {
+				t.Errorf("Write() = %v, want %v", err.Error(), nil)
+			}
+		}
+		if err := w.FlushBits(); err != nil {
+			t.Errorf("FlushBits() = %v, want %v", err.Error(), nil)
+		}
+
+		if bitsWritten := w.NumBitsWritten(); bitsWritten != 90 {
+			t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, 90)
+		}
+		if result := w.Bytes(); result != nil {
+			t.Errorf("Bytes() = %v, want %v", result, nil)
+		}
+	})
+
 	t.Run("error when writing when writer was already flushed", func(t *testing.T) {
 		t.Parallel()
 
@@ -231,3 +276,27 @@ func TestReader(t *testing.T) {
 		}
 	})
 }
+
+// FuzzReader reads arbitrary data with arbitrary widths, Read must never read past the whole words of data
+func FuzzReader(f *testing.F) {
+	f.Add([]byte{255, 100, 255, 1}, []byte{16, 16})
+	f.Add([]byte{255, 100, 255, 1, 100, 234, 90, 0}, []byte{26, 13, 32})
+	f.Add([]byte{1, 2, 3}, []byte{8})
+	f.Add([]byte{}, []byte{0, 33, 1})
+
+	f.Fuzz(func(t *testing.T, data []byte, widths []byte) {
+		r := bitpacker.NewReader(bytes.NewReader(data), len(data))
+		for _, width := range widths {
+			before := r.NumBitsRead()
+			if _, err := r.Read(int(width)); err != nil {
+				if r.NumBitsRead() != before {
+					t.Errorf("NumBitsRead() = %v after a failed Read(), want %v", r.NumBitsRead(), before)
+				}
+				continue
+			}
+			if r.NumBitsRead()+r.NumBitsRemaining() > len(data)/4*32 {
+				t.Errorf("NumBitsRead() = %v, more than the %v bits of data", r.NumBitsRead(), len(data)/4*32)
+			}
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"math/bits"
 
 	"github.com/trphume/coachbuf/internal/bitpacker"
 )
@@ -19,7 +20,7 @@ func WriteInteger(writer *bitpacker.Writer, value, min, max int32) error {
 	if min > max || value > max || value < min || min == max {
 		return fmt.Errorf("value=%d, min=%d, max=%d: %w", value, min, max, ErrInvalidArgument)
 	}
-	bits := bitpacker.BitsRequired(uint32(max - min))
+	bits := IntegerBits(min, max)
 	unsignedValue := uint32(value - min)
 
 	err := writer.Write(unsignedValue, bits)
@@ -39,7 +40,7 @@ func ReadInteger(reader *bitpacker.Reader, min, max int32) (int32, error) {
 	if min > max || min == max {
 		return 0, fmt.Errorf("min=%d, max=%d: %w", min, max, ErrInvalidArgument)
 	}
-	bits := bitpacker.BitsRequired(uint32(max - min))
+	bits := IntegerBits(min, max)
 
 	unsignedValue, err := reader.Read(bits)
 	if err != nil {
@@ -55,6 +56,24 @@ func ReadInteger(reader *bitpacker.Reader, min, max int32) (int32, error) {
 	return value, nil
 }
 
+// ReadIntegerStrict reads an integer like ReadInteger but rejects bit patterns decoding outside [min, max]
+// which are possible when max - min + 1 is not a power of two
+func ReadIntegerStrict(reader *bitpacker.Reader, min, max int32) (int32, error) {
+	if min > max || min == max {
+		return 0, fmt.Errorf("min=%d, max=%d: %w", min, max, ErrInvalidArgument)
+	}
+
+	unsignedValue, err := reader.Read(IntegerBits(min, max))
+	if err != nil {
+		return 0, err
+	}
+	if unsignedValue > uint32(max-min) {
+		return 0, fmt.Errorf("value=%d, min=%d, max=%d: %w", int64(min)+int64(unsignedValue), min, max, ErrOutOfRange)
+	}
+
+	return int32(unsignedValue) + min, nil
+}
+
 // WriteFloat and ReadFloat are meant to be used together
 // Assumptions made by ReadFloat regarding overflow are only valid for buffer written with WriteFloat
 
@@ -85,6 +104,21 @@ func ReadFloat(reader *bitpacker.Reader) (float32, error) {
 	return math.Float32frombits(value), nil
 }
 
+// WriteFloat64 writes float64 value with 64 bits as-is for full precision
+func WriteFloat64(writer *bitpacker.Writer, value float64) error {
+	return writeUint64(writer, math.Float64bits(value), 64)
+}
+
+// ReadFloat64 reads float64 value with 64 bits for full precision
+func ReadFloat64(reader *bitpacker.Reader) (float64, error) {
+	value, err := readUint64(reader, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Float64frombits(value), nil
+}
+
 // WriteCompressedFloat and ReadCompressedFloat are meant to be used together
 // Assumptions made by ReadCompressedFloat regarding overflow are only valid for buffer written with WriteCompressedFloat
 
@@ -92,12 +126,15 @@ func ReadFloat(reader *bitpacker.Reader) (float32, error) {
 // The function is suited for small-mid range values and precision
 // There is a limit to precision value chosen but this function does not handle all edge cases - use within reason
 func WriteCompressedFloat(writer *bitpacker.Writer, value, min, max, res float32) error {
-	if min > max || value > max || value < min || min == max {
+	maxIntegerValue, err := compressedFloatSteps(min, max, res)
+	if err != nil {
+		return err
+	}
+	if !(value >= min && value <= max) {
 		return fmt.Errorf("value=%f, min=%f, max=%f: %w", value, min, max, ErrInvalidArgument)
 	}
 
 	diff := max - min
-	maxIntegerValue := math.Ceil(float64(diff / res))
 	normalizedValue := float64((value - min) / diff)
 	switch {
 	case normalizedValue < 0:
@@ -121,12 +158,11 @@ func WriteCompressedFloat(writer *bitpacker.Writer, value, min, max, res float32
 
 // ReadCompressedFloat reads a compressed float32 value
 func ReadCompressedFloat(reader *bitpacker.Reader, min, max, res float32) (float32, error) {
-	if min > max || min == max {
-		return 0, fmt.Errorf("min=%f, max=%f: %w", min, max, ErrInvalidArgument)
-	}
-
 	diff := max - min
-	maxIntegerValue := math.Ceil(float64(diff / res))
+	maxIntegerValue, err := compressedFloatSteps(min, max, res)
+	if err != nil {
+		return 0, err
+	}
 
 	bits := bitpacker.BitsRequired(uint32(maxIntegerValue))
 	integerValue, err := reader.Read(bits)
@@ -142,3 +178,256 @@ func ReadCompressedFloat(reader *bitpacker.Reader, min, max, res float32) (float
 	value := float32(normalizedValue)*diff + min
 	return value, nil
 }
+
+// ReadCompressedFloatStrict reads a compressed float32 like ReadCompressedFloat
+// but rejects bit patterns decoding outside [min, max]
+func ReadCompressedFloatStrict(reader *bitpacker.Reader, min, max, res float32) (float32, error) {
+	maxIntegerValue, err := compressedFloatSteps(min, max, res)
+	if err != nil {
+		return 0, err
+	}
+	integerValue, err := reader.Read(CompressedFloatBits(min, max, res))
+	if err != nil {
+		return 0, err
+	}
+	if float64(integerValue) > maxIntegerValue {
+		return 0, fmt.Errorf("integer value=%d, steps=%v, min=%f, max=%f: %w", integerValue, maxIntegerValue, min, max, ErrOutOfRange)
+	}
+
+	normalizedValue := float64(integerValue) / maxIntegerValue
+	return float32(normalizedValue)*(max-min) + min, nil
+}
+
+// compressedFloatSteps returns CompressedFloatSteps once the range and precision are checked, an error is returned
+// when min is not below max, res is not positive, any of them is NaN or the steps are zero or do not fit in 32 bits
+func compressedFloatSteps(min, max, res float32) (float64, error) {
+	if !(min < max) || !(res > 0) {
+		return 0, fmt.Errorf("min=%f, max=%f, res=%g: %w", min, max, res, ErrInvalidArgument)
+	}
+
+	steps := CompressedFloatSteps(min, max, res)
+	if !(steps >= 1 && steps <= math.MaxUint32) {
+		return 0, fmt.Errorf("min=%f, max=%f, res=%g require steps=%v, want between 1 and %d: %w", min, max, res, steps, uint32(math.MaxUint32), ErrInvalidArgument)
+	}
+
+	return steps, nil
+}
+
+// WriteVarInteger and ReadVarInteger are meant to be used together
+// Assumptions made by ReadVarInteger regarding group count are only valid for buffer written with WriteVarInteger
+
+// varIntegerGroupBits is the number of value bits carried by each group of a variable-length integer
+const varIntegerGroupBits = 4
+
+// varIntegerMaxGroups is the number of groups needed to carry every bit of an uint32
+const varIntegerMaxGroups = 32 / varIntegerGroupBits
+
+// WriteVarInteger writes a zigzag encoded integer as groups of varIntegerGroupBits bits
+// Each group is followed by a continuation bit which is set when another group follows
+// Values with a small magnitude use fewer bits, the largest magnitude uses 40 bits
+func WriteVarInteger(writer *bitpacker.Writer, value int32) error {
+	unsignedValue := zigzagEncode(value)
+	for {
+		group := unsignedValue & (uint32(1)<<varIntegerGroupBits - 1)
+		unsignedValue >>= varIntegerGroupBits
+
+		var more uint32
+		if unsignedValue != 0 {
+			more = 1
+		}
+
+		if err := writer.Write(group|more<<varIntegerGroupBits, varIntegerGroupBits+1); err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return err
+		}
+		if more == 0 {
+			return nil
+		}
+	}
+}
+
+// ReadVarInteger reads a zigzag encoded integer written in groups with continuation bits
+func ReadVarInteger(reader *bitpacker.Reader) (int32, error) {
+	var unsignedValue uint32
+	for i := 0; i < varIntegerMaxGroups; i++ {
+		group, err := reader.Read(varIntegerGroupBits + 1)
+		if err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return 0, err
+		}
+
+		unsignedValue |= (group & (uint32(1)<<varIntegerGroupBits - 1)) << (i * varIntegerGroupBits)
+		if group>>varIntegerGroupBits == 0 {
+			return zigzagDecode(unsignedValue), nil
+		}
+	}
+
+	return 0, fmt.Errorf("more than %d groups: %w", varIntegerMaxGroups, ErrInvalidEncoding)
+}
+
+// ReadVarIntegerStrict reads a varint like ReadVarInteger but rejects a last group of zero following other groups
+// since WriteVarInteger stops at the highest non-zero group
+func ReadVarIntegerStrict(reader *bitpacker.Reader) (int32, error) {
+	var unsignedValue uint32
+	for i := 0; i < varIntegerMaxGroups; i++ {
+		group, err := reader.Read(varIntegerGroupBits + 1)
+		if err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return 0, err
+		}
+
+		value := group & (uint32(1)<<varIntegerGroupBits - 1)
+		unsignedValue |= value << (i * varIntegerGroupBits)
+
+		if group>>varIntegerGroupBits == 0 {
+			if i > 0 && value == 0 {
+				return 0, fmt.Errorf("last group=%d is zero: %w", i, ErrNonCanonical)
+			}
+			return zigzagDecode(unsignedValue), nil
+		}
+	}
+
+	return 0, fmt.Errorf("more than %d groups: %w", varIntegerMaxGroups, ErrInvalidEncoding)
+}
+
+// WriteGammaInteger and ReadGammaInteger are meant to be used together
+// Assumptions made by ReadGammaInteger regarding prefix length are only valid for buffer written with WriteGammaInteger
+
+// WriteGammaInteger writes a zigzag encoded integer with an Elias-gamma code
+// The zigzag value is offset by one since gamma codes can only represent numbers starting from one
+// A value x is written as n=floor(log2(x)) zero bits, a one bit and then the n bits of x below its leading one
+// The largest magnitude uses 65 bits
+func WriteGammaInteger(writer *bitpacker.Writer, value int32) error {
+	x := uint64(zigzagEncode(value)) + 1
+	n := bits.Len64(x) - 1
+
+	// the prefix and the leading one of x are written as a single value since bits are packed from the lowest bit
+	if err := writeUint64(writer, uint64(1)<<n, n+1); err != nil {
+		return err
+	}
+
+	return writeUint64(writer, x, n)
+}
+
+// ReadGammaInteger reads a zigzag encoded integer written with an Elias-gamma code
+func ReadGammaInteger(reader *bitpacker.Reader) (int32, error) {
+	var n int
+	for {
+		bit, err := reader.Read(1)
+		if err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+
+		n++
+		if n > 32 {
+			return 0, fmt.Errorf("gamma prefix longer than 32 bits: %w", ErrInvalidEncoding)
+		}
+	}
+
+	rest, err := readUint64(reader, n)
+	if err != nil {
+		return 0, err
+	}
+
+	x := uint64(1)<<n | rest
+	if x-1 > math.MaxUint32 {
+		return 0, fmt.Errorf("gamma value=%d exceeds 32 bits: %w", x-1, ErrInvalidEncoding)
+	}
+
+	return zigzagDecode(uint32(x - 1)), nil
+}
+
+// SkipBits reads past the given number of bits, an error is returned without reading when fewer bits remain
+func SkipBits(reader *bitpacker.Reader, numBits int) error {
+	if numBits > reader.NumBitsRemaining() {
+		return fmt.Errorf("skip bits=%d exceeds remaining bits=%d: %w", numBits, reader.NumBitsRemaining(), bitpacker.ErrBitsReadExceeded)
+	}
+
+	for numBits > 0 {
+		chunk := numBits
+		if chunk > 32 {
+			chunk = 32
+		}
+
+		if _, err := reader.Read(chunk); err != nil {
+			return err
+		}
+		numBits -= chunk
+	}
+
+	return nil
+}
+
+// zigzagEncode maps signed integers to unsigned integers so that values with a small magnitude stay small
+func zigzagEncode(value int32) uint32 {
+	return uint32(value<<1) ^ uint32(value>>31)
+}
+
+// zigzagDecode reverses zigzagEncode
+func zigzagDecode(value uint32) int32 {
+	return int32(value>>1) ^ -int32(value&1)
+}
+
+// writeUint64 writes the lowest given number of bits (up to 64) of value, it is a no-op when numBits is 0
+func writeUint64(writer *bitpacker.Writer, value uint64, numBits int) error {
+	for numBits > 0 {
+		chunk := numBits
+		if chunk > 32 {
+			chunk = 32
+		}
+
+		if err := writer.Write(uint32(value), chunk); err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return err
+		}
+		value >>= chunk
+		numBits -= chunk
+	}
+
+	return nil
+}
+
+// readUint64 reads the given number of bits (up to 64) written by writeUint64, it returns 0 when numBits is 0
+func readUint64(reader *bitpacker.Reader, numBits int) (uint64, error) {
+	var value uint64
+	var shift int
+	for numBits > 0 {
+		chunk := numBits
+		if chunk > 32 {
+			chunk = 32
+		}
+
+		v, err := reader.Read(chunk)
+		if err != nil {
+			if errors.Is(err, bitpacker.ErrBitsInvalidRange) {
+				panic("required bits error")
+			}
+
+			return 0, err
+		}
+		value |= uint64(v) << shift
+		shift += chunk
+		numBits -= chunk
+	}
+
+	return value, nil
+}
@@ -80,6 +80,66 @@ func TestReadInteger(t *testing.T) {
 	}
 }
 
+func TestReadIntegerStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		inputMin int32
+		inputMax int32
+		err      error
+		want     int32
+	}{
+		{name: "max value", data: []byte{100, 0, 0, 0}, inputMin: 0, inputMax: 100, err: nil, want: 100},
+		{name: "bit pattern above max", data: []byte{101, 0, 0, 0}, inputMin: 0, inputMax: 100, err: coachwire.ErrOutOfRange, want: 0},
+		{name: "bit pattern above max with negative min", data: []byte{127, 0, 0, 0}, inputMin: -10, inputMax: 100, err: coachwire.ErrOutOfRange, want: 0},
+		{name: "bit pattern above max in 32 bits", data: []byte{255, 255, 255, 255}, inputMin: -10, inputMax: math.MaxInt32, err: coachwire.ErrOutOfRange, want: 0},
+		{name: "full range", data: []byte{255, 255, 255, 255}, inputMin: math.MinInt32, inputMax: math.MaxInt32, err: nil, want: math.MaxInt32},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data))
+
+			result, err := coachwire.ReadIntegerStrict(r, tt.inputMin, tt.inputMax)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ReadIntegerStrict() = %v, want %v", err, tt.err)
+			}
+			if tt.want != result {
+				t.Errorf("ReadIntegerStrict() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCompressedFloatStrict(t *testing.T) {
+	// [0, 10] with a precision of 1 is written in 4 bits, bit patterns above 10 decode outside the range
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+		want float32
+	}{
+		{name: "max value", data: []byte{10, 0, 0, 0}, err: nil, want: 10},
+		{name: "bit pattern above max", data: []byte{11, 0, 0, 0}, err: coachwire.ErrOutOfRange, want: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data))
+
+			result, err := coachwire.ReadCompressedFloatStrict(r, 0, 10, 1)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("ReadCompressedFloatStrict() = %v, want %v", err, tt.err)
+			}
+			if tt.want != result {
+				t.Errorf("ReadCompressedFloatStrict() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
 func TestWriteAndReadInteger(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -163,6 +223,46 @@ func TestWriteAndReadFloat(t *testing.T) {
 	}
 }
 
+func TestWriteAndReadFloat64(t *testing.T) {
+	tests := []struct {
+		name  string
+		value float64
+	}{
+		{name: "negative value", value: -123.33},
+		{name: "positive value", value: 424359.4349},
+		{name: "max value", value: math.MaxFloat64},
+		{name: "smallest value", value: math.SmallestNonzeroFloat64},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteFloat64(w, tt.value); err != nil {
+				t.Errorf("WriteFloat64() = %v, want %v", err, nil)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadFloat64(r)
+			if err != nil {
+				t.Errorf("ReadFloat64() = %v, want %v", err, nil)
+			}
+
+			if result != tt.value {
+				t.Errorf("WriteFloat64() and ReadFloat64() = %v, want %v", result, tt.value)
+			}
+		})
+	}
+}
+
 func TestWriteCompressedFloat(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -177,6 +277,13 @@ func TestWriteCompressedFloat(t *testing.T) {
 		{name: "value > max", inputValue: 5000000, inputMin: 50, inputMax: 100000, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: nil},
 		{name: "value < min", inputValue: 100, inputMin: 100000, inputMax: 5000000, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: nil},
 		{name: "min == max", inputValue: 100, inputMin: 100, inputMax: 100, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "steps exceed 32 bits", inputValue: 12345, inputMin: -1000000, inputMax: 1000000, inputRes: 0.000001, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "zero steps", inputValue: 0, inputMin: 0, inputMax: 1e-38, inputRes: 1e38, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "negative res", inputValue: 0.5, inputMin: 0, inputMax: 1, inputRes: -1, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "zero res", inputValue: 0.5, inputMin: 0, inputMax: 1, inputRes: 0, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "NaN value", inputValue: float32(math.NaN()), inputMin: 0, inputMax: 1, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "NaN min", inputValue: 0.5, inputMin: float32(math.NaN()), inputMax: 1, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: nil},
+		{name: "NaN res", inputValue: 0.5, inputMin: 0, inputMax: 1, inputRes: float32(math.NaN()), err: coachwire.ErrInvalidArgument, want: nil},
 		{name: "valid input", inputValue: 5000.12345, inputMin: 1000.54321, inputMax: 10000.54321, inputRes: 0.001, err: nil, want: []byte{92, 7, 61, 0}},
 	}
 	for _, tt := range tests {
@@ -213,6 +320,9 @@ func TestReadCompressedFloat(t *testing.T) {
 	}{
 		{name: "min > max", reader: bytes.NewReader([]byte{0, 0, 0, 0}), numBytes: 1, inputMin: 10000, inputMax: 1000, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: 0},
 		{name: "min == max", reader: bytes.NewReader([]byte{0, 0, 0, 0}), numBytes: 1, inputMin: 10000, inputMax: 10000, inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: 0},
+		{name: "zero steps", reader: bytes.NewReader([]byte{0, 0, 0, 0}), numBytes: 1, inputMin: 0, inputMax: 1e-38, inputRes: 1e38, err: coachwire.ErrInvalidArgument, want: 0},
+		{name: "negative res", reader: bytes.NewReader([]byte{0, 0, 0, 0}), numBytes: 1, inputMin: 0, inputMax: 1, inputRes: -1, err: coachwire.ErrInvalidArgument, want: 0},
+		{name: "NaN max", reader: bytes.NewReader([]byte{0, 0, 0, 0}), numBytes: 1, inputMin: 0, inputMax: float32(math.NaN()), inputRes: 0.01, err: coachwire.ErrInvalidArgument, want: 0},
 		{name: "valid all positive", reader: bytes.NewReader([]byte{100, 0, 0, 0}), numBytes: 1, inputMin: 0, inputMax: 2, inputRes: 0.01, err: nil, want: 1},
 		{name: "valid all negative", reader: bytes.NewReader([]byte{100, 0, 0, 0}), numBytes: 1, inputMin: -2, inputMax: 0, inputRes: 0.01, err: nil, want: -1},
 		{name: "valid negative min and positive max", reader: bytes.NewReader([]byte{238, 2, 0, 0}), numBytes: 2, inputMin: -5, inputMax: 5, inputRes: 0.01, err: nil, want: 2.5},
@@ -277,3 +387,149 @@ func TestWriteAndReadCompressedFloat(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteAndReadVarInteger(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int32
+		wantBits int
+	}{
+		{name: "zero", value: 0, wantBits: 5},
+		{name: "small positive", value: 7, wantBits: 5},
+		{name: "small negative", value: -8, wantBits: 5},
+		{name: "two groups", value: 8, wantBits: 10},
+		{name: "biggest value", value: math.MaxInt32, wantBits: 40},
+		{name: "smallest value", value: math.MinInt32, wantBits: 40},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteVarInteger(w, tt.value); err != nil {
+				t.Errorf("WriteVarInteger() = %v, want %v", err, nil)
+			}
+			if bitsWritten := w.NumBitsWritten(); bitsWritten != tt.wantBits {
+				t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, tt.wantBits)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadVarInteger(r)
+			if err != nil {
+				t.Errorf("ReadVarInteger() = %v, want %v", err, nil)
+			}
+
+			if result != tt.value {
+				t.Errorf("WriteVarInteger() and ReadVarInteger() = %v, want %v", result, tt.value)
+			}
+		})
+	}
+}
+
+func TestReadVarInteger(t *testing.T) {
+	t.Run("error when continuation bit set on every group", func(t *testing.T) {
+		t.Parallel()
+
+		r := bitpacker.NewReader(bytes.NewReader([]byte{255, 255, 255, 255, 255, 255, 255, 255}), 8)
+		if _, err := coachwire.ReadVarInteger(r); !errors.Is(err, coachwire.ErrInvalidEncoding) {
+			t.Errorf("ReadVarInteger() = %v, want %v", err, coachwire.ErrInvalidEncoding)
+		}
+	})
+}
+
+func TestReadVarIntegerStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want int32
+		err  error
+	}{
+		// groups of 4 value bits and a continuation bit, zigzag 129 is written as 1 and 8
+		{name: "shortest form", data: []byte{0b00010001, 0b00000001, 0, 0}, want: -65, err: nil},
+		// a group of 0 with a continuation bit followed by a group of 0
+		{name: "zero group after a continuation bit", data: []byte{0b00010000, 0, 0, 0}, err: coachwire.ErrNonCanonical},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// every encoding is accepted by ReadVarInteger
+			if _, err := coachwire.ReadVarInteger(bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data))); err != nil {
+				t.Errorf("ReadVarInteger() = %v, want %v", err, nil)
+			}
+
+			result, err := coachwire.ReadVarIntegerStrict(bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data)))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ReadVarIntegerStrict() = %v, want %v", err, tt.err)
+			}
+			if err == nil && result != tt.want {
+				t.Errorf("ReadVarIntegerStrict() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAndReadGammaInteger(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int32
+		wantBits int
+	}{
+		{name: "zero", value: 0, wantBits: 1},
+		{name: "small negative", value: -1, wantBits: 3},
+		{name: "small positive", value: 1, wantBits: 3},
+		{name: "biggest value", value: math.MaxInt32, wantBits: 63},
+		{name: "smallest value", value: math.MinInt32, wantBits: 65},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteGammaInteger(w, tt.value); err != nil {
+				t.Errorf("WriteGammaInteger() = %v, want %v", err, nil)
+			}
+			if bitsWritten := w.NumBitsWritten(); bitsWritten != tt.wantBits {
+				t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, tt.wantBits)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadGammaInteger(r)
+			if err != nil {
+				t.Errorf("ReadGammaInteger() = %v, want %v", err, nil)
+			}
+
+			if result != tt.value {
+				t.Errorf("WriteGammaInteger() and ReadGammaInteger() = %v, want %v", result, tt.value)
+			}
+		})
+	}
+}
+
+func TestReadGammaInteger(t *testing.T) {
+	t.Run("error when prefix exceeds 32 zero bits", func(t *testing.T) {
+		t.Parallel()
+
+		r := bitpacker.NewReader(bytes.NewReader([]byte{0, 0, 0, 0, 0, 0, 0, 0}), 8)
+		if _, err := coachwire.ReadGammaInteger(r); !errors.Is(err, coachwire.ErrInvalidEncoding) {
+			t.Errorf("ReadGammaInteger() = %v, want %v", err, coachwire.ErrInvalidEncoding)
+		}
+	})
+}
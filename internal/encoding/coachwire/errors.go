@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrInvalidArgument = errors.New("invalid argument")
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	ErrOutOfRange      = errors.New("value out of range")
+	ErrNonCanonical    = errors.New("non-canonical encoding")
 )
@@ -0,0 +1,97 @@
+package coachwire_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// FuzzRead reads arbitrary data with every read function, they must return an error rather than panic
+func FuzzRead(f *testing.F) {
+	f.Add([]byte{0, 0, 0, 0})
+	f.Add([]byte{255, 255, 255, 255, 255, 255, 255, 255})
+	f.Add([]byte{0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0})
+	f.Add([]byte{255, 3, 0, 0, 1, 2, 3, 4})
+	f.Add([]byte{0xFE, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0})
+
+	reads := []func(r *bitpacker.Reader) error{
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadInteger(r, -100, 100); return err },
+		func(r *bitpacker.Reader) error {
+			_, err := coachwire.ReadInteger(r, math.MinInt32, math.MaxInt32)
+			return err
+		},
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadIntegerStrict(r, -100, 100); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadFloat(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadFloat64(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadCompressedFloat(r, -10, 10, 0.01); return err },
+		func(r *bitpacker.Reader) error {
+			_, err := coachwire.ReadCompressedFloatStrict(r, -10, 10, 0.01)
+			return err
+		},
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadVarInteger(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadVarIntegerStrict(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadGammaInteger(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadAddr(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadAddrPort(r); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadBigInt(r, 256); return err },
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadBigIntStrict(r, 256); return err },
+		func(r *bitpacker.Reader) error {
+			_, err := coachwire.ReadBigInt(r, math.MaxInt32)
+			return err
+		},
+		func(r *bitpacker.Reader) error { _, err := coachwire.ReadBytes(r, 1024); return err },
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, read := range reads {
+			r := bitpacker.NewReader(bytes.NewReader(data), len(data))
+			for read(r) == nil && r.NumBitsRemaining() > 0 {
+				// keep reading until the data is exhausted or rejected
+			}
+		}
+	})
+}
+
+// FuzzWriteAndRead writes arbitrary values and reads them back, every value must survive the round trip
+func FuzzWriteAndRead(f *testing.F) {
+	f.Add(int32(0), 0.0, []byte{})
+	f.Add(int32(math.MinInt32), math.Inf(-1), []byte{1, 2, 3})
+	f.Add(int32(math.MaxInt32), math.SmallestNonzeroFloat64, bytes.Repeat([]byte{255}, 64))
+
+	f.Fuzz(func(t *testing.T, value int32, float float64, data []byte) {
+		if len(data) > 64 {
+			data = data[:64]
+		}
+
+		w := bitpacker.NewWriter()
+		for _, err := range []error{
+			coachwire.WriteVarInteger(w, value),
+			coachwire.WriteGammaInteger(w, value),
+			coachwire.WriteFloat64(w, float),
+			coachwire.WriteBytes(w, data, 64),
+			w.FlushBits(),
+		} {
+			if err != nil {
+				t.Fatalf("write = %v, want %v", err, nil)
+			}
+		}
+
+		b := w.Bytes()
+		r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+		if result, err := coachwire.ReadVarInteger(r); err != nil || result != value {
+			t.Errorf("ReadVarInteger() = %v, %v, want %v, %v", result, err, value, nil)
+		}
+		if result, err := coachwire.ReadGammaInteger(r); err != nil || result != value {
+			t.Errorf("ReadGammaInteger() = %v, %v, want %v, %v", result, err, value, nil)
+		}
+		if result, err := coachwire.ReadFloat64(r); err != nil || math.Float64bits(result) != math.Float64bits(float) {
+			t.Errorf("ReadFloat64() = %v, %v, want %v, %v", result, err, float, nil)
+		}
+		if result, err := coachwire.ReadBytes(r, 64); err != nil || !bytes.Equal(result, data) {
+			t.Errorf("ReadBytes() = %v, %v, want %v, %v", result, err, data, nil)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package coachwire
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// number of bits written by the functions of this package whose size does not depend on arguments
+const (
+	FloatBits   = 32
+	Float64Bits = 64
+
+	VarIntegerMinBits = varIntegerGroupBits + 1
+	VarIntegerMaxBits = varIntegerMaxGroups * (varIntegerGroupBits + 1)
+
+	GammaIntegerMinBits = 1
+	GammaIntegerMaxBits = 2*32 + 1
+
+	AddrMinBits     = 1 + 32
+	AddrMaxBits     = 1 + 128
+	AddrPortMinBits = AddrMinBits + 16
+	AddrPortMaxBits = AddrMaxBits + 16
+)
+
+// IntegerBits returns the number of bits written by WriteInteger for the range [min, max]
+func IntegerBits(min, max int32) int {
+	return bitpacker.BitsRequired(uint32(max - min))
+}
+
+// CompressedFloatSteps returns the number of steps of size res in the range [min, max], the largest integer written
+// by WriteCompressedFloat, a range and precision are only supported when it is at most math.MaxUint32
+func CompressedFloatSteps(min, max, res float32) float64 {
+	return math.Ceil(float64((max - min) / res))
+}
+
+// CompressedFloatBits returns the number of bits written by WriteCompressedFloat for the range [min, max] and precision res,
+// 33 is returned when the steps of the range do not fit in 32 bits
+func CompressedFloatBits(min, max, res float32) int {
+	steps := CompressedFloatSteps(min, max, res)
+	if !(steps <= math.MaxUint32) {
+		return 33
+	}
+
+	return bitpacker.BitsRequired(uint32(steps))
+}
+
+// BigIntBits returns the minimum and maximum number of bits written by WriteBigInt for maxBits
+func BigIntBits(maxBits int) (int, int) {
+	header := 1 + bitpacker.BitsRequired(uint32(maxBits))
+	return header, header + maxBits
+}
+
+// BytesBits returns the minimum and maximum number of bits written by WriteBytes for maxLen
+func BytesBits(maxLen int) (int, int) {
+	header := IntegerBits(0, int32(maxLen))
+	return header, header + 8*maxLen
+}
+
+// GammaIntegerBits returns the number of bits written by WriteGammaInteger for value
+func GammaIntegerBits(value int32) int {
+	return 2*(bits.Len64(uint64(zigzagEncode(value))+1)-1) + 1
+}
@@ -0,0 +1,300 @@
+package coachwire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// WriteAddr and ReadAddr are meant to be used together
+
+// WriteAddr writes a 1 bit discriminator (0 for IPv4, 1 for IPv6) followed by the 32 or 128 bits of the address
+// The zero Addr and an IPv6 address with a zone can not be written, an absent address is written as Optional[netip.Addr]
+func WriteAddr(writer *bitpacker.Writer, addr netip.Addr) error {
+	if !addr.IsValid() {
+		return fmt.Errorf("zero Addr, use Optional[netip.Addr] for an absent address: %w", ErrInvalidArgument)
+	}
+	if addr.Zone() != "" {
+		return fmt.Errorf("addr=%v has zone=%s, the zone is not written: %w", addr, addr.Zone(), ErrInvalidArgument)
+	}
+
+	if addr.Is4() {
+		if err := writeUint64(writer, 0, 1); err != nil {
+			return err
+		}
+		a := addr.As4()
+		return writeUint64(writer, uint64(binary.BigEndian.Uint32(a[:])), 32)
+	}
+
+	if err := writeUint64(writer, 1, 1); err != nil {
+		return err
+	}
+	a := addr.As16()
+	if err := writeUint64(writer, binary.BigEndian.Uint64(a[:8]), 64); err != nil {
+		return err
+	}
+
+	return writeUint64(writer, binary.BigEndian.Uint64(a[8:]), 64)
+}
+
+// ReadAddr reads an IPv4 or IPv6 address written by WriteAddr
+func ReadAddr(reader *bitpacker.Reader) (netip.Addr, error) {
+	isIPv6, err := readUint64(reader, 1)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	if isIPv6 == 0 {
+		v, err := readUint64(reader, 32)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		var a [4]byte
+		binary.BigEndian.PutUint32(a[:], uint32(v))
+		return netip.AddrFrom4(a), nil
+	}
+
+	var a [16]byte
+	for i := 0; i < 2; i++ {
+		v, err := readUint64(reader, 64)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		binary.BigEndian.PutUint64(a[i*8:], v)
+	}
+
+	return netip.AddrFrom16(a), nil
+}
+
+// WriteAddrPort writes the address with WriteAddr followed by the 16 bits port
+func WriteAddrPort(writer *bitpacker.Writer, addrPort netip.AddrPort) error {
+	if err := WriteAddr(writer, addrPort.Addr()); err != nil {
+		return err
+	}
+
+	return writeUint64(writer, uint64(addrPort.Port()), 16)
+}
+
+// ReadAddrPort reads an address and port written by WriteAddrPort
+func ReadAddrPort(reader *bitpacker.Reader) (netip.AddrPort, error) {
+	addr, err := ReadAddr(reader)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+
+	port, err := readUint64(reader, 16)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+
+	return netip.AddrPortFrom(addr, uint16(port)), nil
+}
+
+// WriteBigInt and ReadBigInt are meant to be used together
+// Assumptions made by ReadBigInt regarding the bit length are only valid for buffer written with WriteBigInt
+
+// WriteBigInt writes an arbitrary-precision integer whose absolute value fits in maxBits bits
+// The value is written as a sign bit, the bit length of the absolute value and then the absolute value itself
+// A nil value is written as zero
+func WriteBigInt(writer *bitpacker.Writer, value *big.Int, maxBits int) error {
+	if maxBits <= 0 {
+		return fmt.Errorf("maxBits=%d: %w", maxBits, ErrInvalidArgument)
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	bitLen := value.BitLen()
+	if bitLen > maxBits {
+		return fmt.Errorf("value bit length=%d, maxBits=%d: %w", bitLen, maxBits, ErrInvalidArgument)
+	}
+
+	var sign uint64
+	if value.Sign() < 0 {
+		sign = 1
+	}
+	if err := writeUint64(writer, sign, 1); err != nil {
+		return err
+	}
+	if err := writeUint64(writer, uint64(bitLen), bitpacker.BitsRequired(uint32(maxBits))); err != nil {
+		return err
+	}
+
+	// absolute value is written from the least significant byte
+	magnitude := value.Bytes()
+	for i := 0; i < bitLen; i += 8 {
+		chunk := bitLen - i
+		if chunk > 8 {
+			chunk = 8
+		}
+		if err := writeUint64(writer, uint64(magnitude[len(magnitude)-1-i/8]), chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadBigInt reads an arbitrary-precision integer written by WriteBigInt with the same maxBits
+func ReadBigInt(reader *bitpacker.Reader, maxBits int) (*big.Int, error) {
+	negative, bitLen, err := ReadBigIntHeader(reader, maxBits)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBigIntMagnitude(reader, negative, bitLen)
+}
+
+// ReadBigIntStrict reads an integer like ReadBigInt but rejects encodings WriteBigInt never produces:
+// a bit length longer than the magnitude and a negative zero
+func ReadBigIntStrict(reader *bitpacker.Reader, maxBits int) (*big.Int, error) {
+	negative, bitLen, err := ReadBigIntHeader(reader, maxBits)
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadBigIntMagnitudeStrict(reader, negative, bitLen)
+}
+
+// ReadBigIntHeader reads the sign bit and the bit length written by WriteBigInt before the magnitude
+// the bit length is untrusted, it is rejected when it exceeds the remaining bits so that it bounds the size of the magnitude
+func ReadBigIntHeader(reader *bitpacker.Reader, maxBits int) (bool, int, error) {
+	if maxBits <= 0 {
+		return false, 0, fmt.Errorf("maxBits=%d: %w", maxBits, ErrInvalidArgument)
+	}
+
+	sign, err := readUint64(reader, 1)
+	if err != nil {
+		return false, 0, err
+	}
+	bitLen, err := readUint64(reader, bitpacker.BitsRequired(uint32(maxBits)))
+	if err != nil {
+		return false, 0, err
+	}
+	if bitLen > uint64(maxBits) {
+		return false, 0, fmt.Errorf("bit length=%d exceeds maxBits=%d: %w", bitLen, maxBits, ErrInvalidEncoding)
+	}
+	if bitLen > uint64(reader.NumBitsRemaining()) {
+		return false, 0, fmt.Errorf("bit length=%d exceeds remaining bits=%d: %w", bitLen, reader.NumBitsRemaining(), ErrInvalidEncoding)
+	}
+
+	return sign == 1, int(bitLen), nil
+}
+
+// ReadBigIntMagnitude reads the bitLen bits of magnitude following the header read by ReadBigIntHeader
+func ReadBigIntMagnitude(reader *bitpacker.Reader, negative bool, bitLen int) (*big.Int, error) {
+	magnitude := make([]byte, (bitLen+7)/8)
+	for i := 0; i < bitLen; i += 8 {
+		chunk := bitLen - i
+		if chunk > 8 {
+			chunk = 8
+		}
+		v, err := readUint64(reader, chunk)
+		if err != nil {
+			return nil, err
+		}
+		magnitude[len(magnitude)-1-i/8] = byte(v)
+	}
+
+	value := new(big.Int).SetBytes(magnitude)
+	if negative {
+		value.Neg(value)
+	}
+
+	return value, nil
+}
+
+// ReadBigIntMagnitudeStrict reads the magnitude like ReadBigIntMagnitude but rejects a magnitude shorter than bitLen
+// and a negative zero
+func ReadBigIntMagnitudeStrict(reader *bitpacker.Reader, negative bool, bitLen int) (*big.Int, error) {
+	value, err := ReadBigIntMagnitude(reader, negative, bitLen)
+	if err != nil {
+		return nil, err
+	}
+	if value.BitLen() != bitLen {
+		return nil, fmt.Errorf("bit length=%d, magnitude bit length=%d: %w", bitLen, value.BitLen(), ErrNonCanonical)
+	}
+	if negative && bitLen == 0 {
+		return nil, fmt.Errorf("negative zero: %w", ErrNonCanonical)
+	}
+
+	return value, nil
+}
+
+// SkipBigInt reads past an integer written by WriteBigInt with the same maxBits without allocating it
+func SkipBigInt(reader *bitpacker.Reader, maxBits int) error {
+	_, bitLen, err := ReadBigIntHeader(reader, maxBits)
+	if err != nil {
+		return err
+	}
+
+	return SkipBits(reader, bitLen)
+}
+
+// WriteBytes and ReadBytes are meant to be used together
+
+// WriteBytes writes a slice of at most maxLen bytes as its length in the range [0, maxLen] followed by every byte
+func WriteBytes(writer *bitpacker.Writer, value []byte, maxLen int) error {
+	if maxLen <= 0 || maxLen > math.MaxInt32 {
+		return fmt.Errorf("maxLen=%d: %w", maxLen, ErrInvalidArgument)
+	}
+	if len(value) > maxLen {
+		return fmt.Errorf("length=%d exceeds maxLen=%d: %w", len(value), maxLen, ErrInvalidArgument)
+	}
+
+	if err := WriteInteger(writer, int32(len(value)), 0, int32(maxLen)); err != nil {
+		return err
+	}
+	for _, b := range value {
+		if err := writer.Write(uint32(b), 8); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadBytes reads a slice of bytes written by WriteBytes with the same maxLen
+func ReadBytes(reader *bitpacker.Reader, maxLen int) ([]byte, error) {
+	if maxLen <= 0 || maxLen > math.MaxInt32 {
+		return nil, fmt.Errorf("maxLen=%d: %w", maxLen, ErrInvalidArgument)
+	}
+
+	length, err := ReadInteger(reader, 0, int32(maxLen))
+	if err != nil {
+		return nil, err
+	}
+	// the length is untrusted, nothing is allocated for bytes that are not present
+	if int(length)*8 > reader.NumBitsRemaining() {
+		return nil, fmt.Errorf("length=%d exceeds remaining bits=%d: %w", length, reader.NumBitsRemaining(), ErrInvalidEncoding)
+	}
+
+	value := make([]byte, length)
+	for i := range value {
+		b, err := reader.Read(8)
+		if err != nil {
+			return nil, err
+		}
+		value[i] = byte(b)
+	}
+
+	return value, nil
+}
+
+// SkipBytes reads past a slice of bytes written by WriteBytes with the same maxLen without allocating it
+func SkipBytes(reader *bitpacker.Reader, maxLen int) error {
+	if maxLen <= 0 || maxLen > math.MaxInt32 {
+		return fmt.Errorf("maxLen=%d: %w", maxLen, ErrInvalidArgument)
+	}
+
+	length, err := ReadInteger(reader, 0, int32(maxLen))
+	if err != nil {
+		return err
+	}
+
+	return SkipBits(reader, int(length)*8)
+}
@@ -0,0 +1,354 @@
+package coachwire_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"net/netip"
+	"testing"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+func TestWriteAndReadAddrPort(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    netip.AddrPort
+		wantBits int
+	}{
+		{name: "IPv4", value: netip.MustParseAddrPort("192.168.1.20:8080"), wantBits: 1 + 32 + 16},
+		{name: "IPv6", value: netip.MustParseAddrPort("[2001:db8::1]:443"), wantBits: 1 + 128 + 16},
+		{name: "IPv4 mapped IPv6", value: netip.MustParseAddrPort("[::ffff:10.0.0.1]:0"), wantBits: 1 + 128 + 16},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteAddrPort(w, tt.value); err != nil {
+				t.Errorf("WriteAddrPort() = %v, want %v", err, nil)
+			}
+			if bitsWritten := w.NumBitsWritten(); bitsWritten != tt.wantBits {
+				t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, tt.wantBits)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadAddrPort(r)
+			if err != nil {
+				t.Errorf("ReadAddrPort() = %v, want %v", err, nil)
+			}
+
+			if result != tt.value {
+				t.Errorf("WriteAddrPort() and ReadAddrPort() = %v, want %v", result, tt.value)
+			}
+		})
+	}
+}
+
+func TestWriteAddr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value netip.Addr
+	}{
+		{name: "zero Addr", value: netip.Addr{}},
+		{name: "IPv6 with zone", value: netip.MustParseAddr("fe80::1%eth0")},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteAddr(w, tt.value); !errors.Is(err, coachwire.ErrInvalidArgument) {
+				t.Errorf("WriteAddr() = %v, want %v", err, coachwire.ErrInvalidArgument)
+			}
+			if bitsWritten := w.NumBitsWritten(); bitsWritten != 0 {
+				t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, 0)
+			}
+		})
+	}
+}
+
+func TestWriteAndReadBigInt(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+
+	tests := []struct {
+		name    string
+		value   *big.Int
+		maxBits int
+		want    *big.Int
+	}{
+		{name: "nil", value: nil, maxBits: 8, want: big.NewInt(0)},
+		{name: "zero", value: big.NewInt(0), maxBits: 8, want: big.NewInt(0)},
+		{name: "exact max bits", value: big.NewInt(255), maxBits: 8, want: big.NewInt(255)},
+		{name: "negative beyond 64 bits", value: huge, maxBits: 128, want: huge},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteBigInt(w, tt.value, tt.maxBits); err != nil {
+				t.Errorf("WriteBigInt() = %v, want %v", err, nil)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadBigInt(r, tt.maxBits)
+			if err != nil {
+				t.Errorf("ReadBigInt() = %v, want %v", err, nil)
+			}
+
+			if result.Cmp(tt.want) != 0 {
+				t.Errorf("WriteBigInt() and ReadBigInt() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteBigInt(t *testing.T) {
+	t.Run("error when value exceeds max bits", func(t *testing.T) {
+		t.Parallel()
+
+		w := bitpacker.NewWriter()
+		if err := coachwire.WriteBigInt(w, big.NewInt(256), 8); !errors.Is(err, coachwire.ErrInvalidArgument) {
+			t.Errorf("WriteBigInt() = %v, want %v", err, coachwire.ErrInvalidArgument)
+		}
+	})
+}
+
+func TestReadBigInt(t *testing.T) {
+	t.Run("error when bit length exceeds max bits", func(t *testing.T) {
+		t.Parallel()
+
+		// sign bit 0 followed by a 4 bits length of 9
+		r := bitpacker.NewReader(bytes.NewReader([]byte{0b00010010, 0, 0, 0}), 4)
+		if _, err := coachwire.ReadBigInt(r, 8); !errors.Is(err, coachwire.ErrInvalidEncoding) {
+			t.Errorf("ReadBigInt() = %v, want %v", err, coachwire.ErrInvalidEncoding)
+		}
+	})
+
+	t.Run("error when bit length exceeds remaining bits", func(t *testing.T) {
+		t.Parallel()
+
+		// sign bit 0 followed by a 31 bits length of 2147483647
+		r := bitpacker.NewReader(bytes.NewReader([]byte{0xFE, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}), 8)
+		if _, err := coachwire.ReadBigInt(r, math.MaxInt32); !errors.Is(err, coachwire.ErrInvalidEncoding) {
+			t.Errorf("ReadBigInt() = %v, want %v", err, coachwire.ErrInvalidEncoding)
+		}
+	})
+}
+
+func TestReadBigIntStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		// sign bit 0, a 4 bits length of 2 and a magnitude of 1
+		{name: "bit length longer than magnitude", data: []byte{0b00100100, 0, 0, 0}},
+		// sign bit 1 and a 4 bits length of 0
+		{name: "negative zero", data: []byte{0b00000001, 0, 0, 0}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := coachwire.ReadBigInt(bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data)), 8); err != nil {
+				t.Errorf("ReadBigInt() = %v, want %v", err, nil)
+			}
+			if _, err := coachwire.ReadBigIntStrict(bitpacker.NewReader(bytes.NewReader(tt.data), len(tt.data)), 8); !errors.Is(err, coachwire.ErrNonCanonical) {
+				t.Errorf("ReadBigIntStrict() = %v, want %v", err, coachwire.ErrNonCanonical)
+			}
+		})
+	}
+}
+
+func TestWriteAndReadBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    []byte
+		maxLen   int
+		wantBits int
+	}{
+		{name: "empty", value: []byte{}, maxLen: 1, wantBits: 1},
+		{name: "exact max length", value: []byte{1, 2, 3}, maxLen: 3, wantBits: 2 + 3*8},
+		{name: "bounded length prefix", value: []byte("coachbuf"), maxLen: 1024, wantBits: 11 + 8*8},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// write
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteBytes(w, tt.value, tt.maxLen); err != nil {
+				t.Errorf("WriteBytes() = %v, want %v", err, nil)
+			}
+			if bitsWritten := w.NumBitsWritten(); bitsWritten != tt.wantBits {
+				t.Errorf("NumBitsWritten() = %v, want %v", bitsWritten, tt.wantBits)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Errorf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			b := w.Bytes()
+
+			// read
+			r := bitpacker.NewReader(bytes.NewReader(b), len(b))
+			result, err := coachwire.ReadBytes(r, tt.maxLen)
+			if err != nil {
+				t.Errorf("ReadBytes() = %v, want %v", err, nil)
+			}
+
+			if !bytes.Equal(result, tt.value) {
+				t.Errorf("WriteBytes() and ReadBytes() = %v, want %v", result, tt.value)
+			}
+		})
+	}
+}
+
+func TestWriteBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  []byte
+		maxLen int
+	}{
+		{name: "length exceeds maxLen", value: []byte{1, 2, 3}, maxLen: 2},
+		{name: "non positive maxLen", value: nil, maxLen: 0},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := bitpacker.NewWriter()
+			if err := coachwire.WriteBytes(w, tt.value, tt.maxLen); !errors.Is(err, coachwire.ErrInvalidArgument) {
+				t.Errorf("WriteBytes() = %v, want %v", err, coachwire.ErrInvalidArgument)
+			}
+		})
+	}
+}
+
+func TestSizeBits(t *testing.T) {
+	t.Parallel()
+
+	// the reported sizes must match the number of bits actually written
+	w := bitpacker.NewWriter()
+	if err := coachwire.WriteBigInt(w, new(big.Int).Lsh(big.NewInt(1), 99), 100); err != nil {
+		t.Fatalf("WriteBigInt() = %v, want %v", err, nil)
+	}
+	if _, max := coachwire.BigIntBits(100); w.NumBitsWritten() != max {
+		t.Errorf("BigIntBits() = %v, want %v", max, w.NumBitsWritten())
+	}
+
+	w = bitpacker.NewWriter()
+	if err := coachwire.WriteBytes(w, nil, 300); err != nil {
+		t.Fatalf("WriteBytes() = %v, want %v", err, nil)
+	}
+	if min, _ := coachwire.BytesBits(300); w.NumBitsWritten() != min {
+		t.Errorf("BytesBits() = %v, want %v", min, w.NumBitsWritten())
+	}
+
+	w = bitpacker.NewWriter()
+	if err := coachwire.WriteGammaInteger(w, math.MinInt32); err != nil {
+		t.Fatalf("WriteGammaInteger() = %v, want %v", err, nil)
+	}
+	if w.NumBitsWritten() != coachwire.GammaIntegerMaxBits {
+		t.Errorf("GammaIntegerMaxBits = %v, want %v", coachwire.GammaIntegerMaxBits, w.NumBitsWritten())
+	}
+
+	for _, value := range []int32{0, 1, 300, math.MaxInt32} {
+		w = bitpacker.NewWriter()
+		if err := coachwire.WriteGammaInteger(w, value); err != nil {
+			t.Fatalf("WriteGammaInteger() = %v, want %v", err, nil)
+		}
+		if bits := coachwire.GammaIntegerBits(value); w.NumBitsWritten() != bits {
+			t.Errorf("GammaIntegerBits(%d) = %v, want %v", value, bits, w.NumBitsWritten())
+		}
+	}
+
+	w = bitpacker.NewWriter()
+	if err := coachwire.WriteVarInteger(w, math.MinInt32); err != nil {
+		t.Fatalf("WriteVarInteger() = %v, want %v", err, nil)
+	}
+	if w.NumBitsWritten() != coachwire.VarIntegerMaxBits {
+		t.Errorf("VarIntegerMaxBits = %v, want %v", coachwire.VarIntegerMaxBits, w.NumBitsWritten())
+	}
+}
+
+func TestSkip(t *testing.T) {
+	// every value is followed by a marker that must be read once the value is skipped
+	tests := []struct {
+		name  string
+		write func(w *bitpacker.Writer) error
+		skip  func(r *bitpacker.Reader) error
+	}{
+		{
+			name:  "bits",
+			write: func(w *bitpacker.Writer) error { return coachwire.WriteFloat64(w, math.Pi) },
+			skip:  func(r *bitpacker.Reader) error { return coachwire.SkipBits(r, coachwire.Float64Bits) },
+		},
+		{
+			name:  "big int",
+			write: func(w *bitpacker.Writer) error { return coachwire.WriteBigInt(w, big.NewInt(-1<<40), 64) },
+			skip:  func(r *bitpacker.Reader) error { return coachwire.SkipBigInt(r, 64) },
+		},
+		{
+			name:  "bytes",
+			write: func(w *bitpacker.Writer) error { return coachwire.WriteBytes(w, []byte("skipped"), 16) },
+			skip:  func(r *bitpacker.Reader) error { return coachwire.SkipBytes(r, 16) },
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := bitpacker.NewWriter()
+			if err := tt.write(w); err != nil {
+				t.Fatalf("write = %v, want %v", err, nil)
+			}
+			if err := coachwire.WriteInteger(w, 42, 0, 100); err != nil {
+				t.Fatalf("WriteInteger() = %v, want %v", err, nil)
+			}
+			if err := w.FlushBits(); err != nil {
+				t.Fatalf("FlushBits() = %v, want %v", err, nil)
+			}
+
+			r := bitpacker.NewReader(bytes.NewReader(w.Bytes()), len(w.Bytes()))
+			if err := tt.skip(r); err != nil {
+				t.Fatalf("skip = %v, want %v", err, nil)
+			}
+			if marker, err := coachwire.ReadInteger(r, 0, 100); err != nil || marker != 42 {
+				t.Errorf("ReadInteger() = %v, %v, want %v", marker, err, 42)
+			}
+		})
+	}
+
+	t.Run("beyond remaining bits", func(t *testing.T) {
+		t.Parallel()
+
+		r := bitpacker.NewReader(bytes.NewReader([]byte{0, 0, 0, 0}), 4)
+		if err := coachwire.SkipBits(r, 33); !errors.Is(err, bitpacker.ErrBitsReadExceeded) {
+			t.Errorf("SkipBits() = %v, want %v", err, bitpacker.ErrBitsReadExceeded)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package coachwire
+
+import (
+	"math/big"
+	"net/netip"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// Stream writes or reads values through the same serialize calls
+//
+// A serialize function taking a Stream describes the wire format once, when the Stream is writing the pointed values
+// are written and when it is reading the pointed values are overwritten with the values read,
+// so the write and the read side of a format can not get out of sync
+type Stream interface {
+	// IsWriting reports whether values are written to the stream
+	IsWriting() bool
+	// IsReading reports whether values are read from the stream
+	IsReading() bool
+
+	// SerializeBits serializes the lowest numBits bits of value, numBits must be in the range (0,32]
+	SerializeBits(value *uint32, numBits int) error
+	// SerializeBool serializes a single bit
+	SerializeBool(value *bool) error
+	// SerializeInt serializes an integer in the range [min, max] where min != max
+	SerializeInt(value *int32, min, max int32) error
+	// SerializeVarInt serializes an integer with WriteVarInteger and ReadVarInteger
+	SerializeVarInt(value *int32) error
+	// SerializeGammaInt serializes an integer with WriteGammaInteger and ReadGammaInteger
+	SerializeGammaInt(value *int32) error
+	// SerializeFloat serializes a float32 with full precision
+	SerializeFloat(value *float32) error
+	// SerializeFloat64 serializes a float64 with full precision
+	SerializeFloat64(value *float64) error
+	// SerializeCompressedFloat serializes a float32 in the range [min, max] with a precision of res
+	SerializeCompressedFloat(value *float32, min, max, res float32) error
+	// SerializeAddr serializes an IPv4 or IPv6 address
+	SerializeAddr(value *netip.Addr) error
+	// SerializeAddrPort serializes an IPv4 or IPv6 address and a port
+	SerializeAddrPort(value *netip.AddrPort) error
+	// SerializeBigInt serializes an arbitrary-precision integer whose absolute value fits in maxBits bits
+	SerializeBigInt(value **big.Int, maxBits int) error
+}
+
+// WriteStream is a Stream writing values to a bitpacker.Writer
+type WriteStream struct {
+	writer *bitpacker.Writer
+}
+
+// NewWriteStream returns a Stream writing to writer
+func NewWriteStream(writer *bitpacker.Writer) *WriteStream {
+	return &WriteStream{writer: writer}
+}
+
+func (s *WriteStream) IsWriting() bool { return true }
+
+func (s *WriteStream) IsReading() bool { return false }
+
+func (s *WriteStream) SerializeBits(value *uint32, numBits int) error {
+	return s.writer.Write(*value, numBits)
+}
+
+func (s *WriteStream) SerializeBool(value *bool) error {
+	if *value {
+		return s.writer.Write(1, 1)
+	}
+
+	return s.writer.Write(0, 1)
+}
+
+func (s *WriteStream) SerializeInt(value *int32, min, max int32) error {
+	return WriteInteger(s.writer, *value, min, max)
+}
+
+func (s *WriteStream) SerializeVarInt(value *int32) error {
+	return WriteVarInteger(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeGammaInt(value *int32) error {
+	return WriteGammaInteger(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeFloat(value *float32) error {
+	return WriteFloat(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeFloat64(value *float64) error {
+	return WriteFloat64(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeCompressedFloat(value *float32, min, max, res float32) error {
+	return WriteCompressedFloat(s.writer, *value, min, max, res)
+}
+
+func (s *WriteStream) SerializeAddr(value *netip.Addr) error {
+	return WriteAddr(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeAddrPort(value *netip.AddrPort) error {
+	return WriteAddrPort(s.writer, *value)
+}
+
+func (s *WriteStream) SerializeBigInt(value **big.Int, maxBits int) error {
+	return WriteBigInt(s.writer, *value, maxBits)
+}
+
+// ReadStream is a Stream reading values from a bitpacker.Reader
+// the pointed values are only overwritten when they were read successfully
+type ReadStream struct {
+	reader *bitpacker.Reader
+}
+
+// NewReadStream returns a Stream reading from reader
+func NewReadStream(reader *bitpacker.Reader) *ReadStream {
+	return &ReadStream{reader: reader}
+}
+
+func (s *ReadStream) IsWriting() bool { return false }
+
+func (s *ReadStream) IsReading() bool { return true }
+
+func (s *ReadStream) SerializeBits(value *uint32, numBits int) error {
+	return assign(value)(s.reader.Read(numBits))
+}
+
+func (s *ReadStream) SerializeBool(value *bool) error {
+	bit, err := s.reader.Read(1)
+	if err != nil {
+		return err
+	}
+	*value = bit == 1
+
+	return nil
+}
+
+func (s *ReadStream) SerializeInt(value *int32, min, max int32) error {
+	return assign(value)(ReadInteger(s.reader, min, max))
+}
+
+func (s *ReadStream) SerializeVarInt(value *int32) error {
+	return assign(value)(ReadVarInteger(s.reader))
+}
+
+func (s *ReadStream) SerializeGammaInt(value *int32) error {
+	return assign(value)(ReadGammaInteger(s.reader))
+}
+
+func (s *ReadStream) SerializeFloat(value *float32) error {
+	return assign(value)(ReadFloat(s.reader))
+}
+
+func (s *ReadStream) SerializeFloat64(value *float64) error {
+	return assign(value)(ReadFloat64(s.reader))
+}
+
+func (s *ReadStream) SerializeCompressedFloat(value *float32, min, max, res float32) error {
+	return assign(value)(ReadCompressedFloat(s.reader, min, max, res))
+}
+
+func (s *ReadStream) SerializeAddr(value *netip.Addr) error {
+	return assign(value)(ReadAddr(s.reader))
+}
+
+func (s *ReadStream) SerializeAddrPort(value *netip.AddrPort) error {
+	return assign(value)(ReadAddrPort(s.reader))
+}
+
+func (s *ReadStream) SerializeBigInt(value **big.Int, maxBits int) error {
+	return assign(value)(ReadBigInt(s.reader, maxBits))
+}
+
+// assign returns a function storing the result of a read function in value when no error occurred
+func assign[T any](value *T) func(T, error) error {
+	return func(v T, err error) error {
+		if err != nil {
+			return err
+		}
+		*value = v
+
+		return nil
+	}
+}
@@ -0,0 +1,112 @@
+package coachwire_test
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"net/netip"
+	"testing"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+type streamValues struct {
+	Bits      uint32
+	Bool      bool
+	Int       int32
+	VarInt    int32
+	GammaInt  int32
+	Float     float32
+	Float64   float64
+	Quantized float32
+	Addr      netip.Addr
+	AddrPort  netip.AddrPort
+	BigInt    *big.Int
+}
+
+// serialize describes the wire format of streamValues once for both directions
+func (v *streamValues) serialize(s coachwire.Stream) error {
+	for _, serialize := range []func() error{
+		func() error { return s.SerializeBits(&v.Bits, 5) },
+		func() error { return s.SerializeBool(&v.Bool) },
+		func() error { return s.SerializeInt(&v.Int, -10, 10) },
+		func() error { return s.SerializeVarInt(&v.VarInt) },
+		func() error { return s.SerializeGammaInt(&v.GammaInt) },
+		func() error { return s.SerializeFloat(&v.Float) },
+		func() error { return s.SerializeFloat64(&v.Float64) },
+		func() error { return s.SerializeCompressedFloat(&v.Quantized, 0, 10, 0.5) },
+		func() error { return s.SerializeAddr(&v.Addr) },
+		func() error { return s.SerializeAddrPort(&v.AddrPort) },
+		func() error { return s.SerializeBigInt(&v.BigInt, 80) },
+	} {
+		if err := serialize(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	input := streamValues{
+		Bits:      19,
+		Bool:      true,
+		Int:       -7,
+		VarInt:    -1234,
+		GammaInt:  42,
+		Float:     3.25,
+		Float64:   -1e100,
+		Quantized: 7.5,
+		Addr:      netip.MustParseAddr("2001:db8::1"),
+		AddrPort:  netip.MustParseAddrPort("10.0.0.1:8080"),
+		BigInt:    new(big.Int).Lsh(big.NewInt(-3), 70),
+	}
+
+	w := bitpacker.NewWriter()
+	ws := coachwire.NewWriteStream(w)
+	if !ws.IsWriting() || ws.IsReading() {
+		t.Errorf("IsWriting(), IsReading() = %v, %v, want %v, %v", ws.IsWriting(), ws.IsReading(), true, false)
+	}
+	if err := input.serialize(ws); err != nil {
+		t.Fatalf("serialize() = %v, want %v", err.Error(), nil)
+	}
+	if err := w.FlushBits(); err != nil {
+		t.Fatalf("FlushBits() = %v, want %v", err.Error(), nil)
+	}
+
+	data := w.Bytes()
+	rs := coachwire.NewReadStream(bitpacker.NewReader(bytes.NewReader(data), len(data)))
+	if rs.IsWriting() || !rs.IsReading() {
+		t.Errorf("IsWriting(), IsReading() = %v, %v, want %v, %v", rs.IsWriting(), rs.IsReading(), false, true)
+	}
+
+	var result streamValues
+	if err := result.serialize(rs); err != nil {
+		t.Fatalf("serialize() = %v, want %v", err.Error(), nil)
+	}
+
+	if result.BigInt.Cmp(input.BigInt) != 0 {
+		t.Errorf("SerializeBigInt() = %v, want %v", result.BigInt, input.BigInt)
+	}
+	result.BigInt = input.BigInt
+	if result != input {
+		t.Errorf("serialize() = %+v, want %+v", result, input)
+	}
+}
+
+func TestReadStreamError(t *testing.T) {
+	t.Parallel()
+
+	// the pointed value must be left untouched when reading fails
+	rs := coachwire.NewReadStream(bitpacker.NewReader(bytes.NewReader([]byte{}), 0))
+	value := int32(5)
+	if err := rs.SerializeInt(&value, 0, 10); !errors.Is(err, bitpacker.ErrBitsReadExceeded) {
+		t.Errorf("SerializeInt() = %v, want %v", err, bitpacker.ErrBitsReadExceeded)
+	}
+	if value != 5 {
+		t.Errorf("SerializeInt() value = %v, want %v", value, 5)
+	}
+}
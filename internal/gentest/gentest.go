@@ -0,0 +1,79 @@
+// Package gentest holds types with generated Coachbuf methods used to test cmd/coachbuf-gen
+package gentest
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+
+	"github.com/trphume/coachbuf"
+)
+
+//go:generate go run ../../cmd/coachbuf-gen -type=Player,Header,Unit -output=gentest_coachbuf.go
+
+// Health is a named integer type
+type Health int32
+
+// Player uses every type supported by the generator
+type Player struct {
+	ID       int32                     `coachbuf:"1,min=0,max=100000"`
+	Health   Health                    `coachbuf:"2,min=0,max=100"`
+	Score    int32                     `coachbuf:"3,enc=varint"`
+	Rank     int32                     `coachbuf:"4,enc=gamma"`
+	Default  int32                     `coachbuf:"5"`
+	Speed    float32                   `coachbuf:"6"`
+	IQ       complex64                 `coachbuf:"7,min=-1,max=1,res=0.001"`
+	Wide     complex128                `coachbuf:"8"`
+	Addr     netip.Addr                `coachbuf:"9"`
+	Endpoint netip.AddrPort            `coachbuf:"10"`
+	Balance  *big.Int                  `coachbuf:"11,maxbits=128"`
+	Level    coachbuf.Optional[int32]  `coachbuf:"12,min=1,max=60"`
+	Header   Header                    `coachbuf:"13"`
+	Position coachbuf.Optional[Vector] `coachbuf:"14"`
+	Packed   Packed                    `coachbuf:"15"`
+	Ignored  string                    // not tagged with coachbuf
+}
+
+// Header is a nested struct type
+type Header struct {
+	Kind    int32 `coachbuf:"200,min=-4,max=3"`
+	Version int32 `coachbuf:"0,enc=varint"`
+	Flags   struct {
+		Urgent int32 `coachbuf:"1,min=0,max=1"`
+	} `coachbuf:"1"`
+}
+
+// Vector is only used nested inside of Player
+type Vector struct {
+	X float32    `coachbuf:"1"`
+	Y complex128 `coachbuf:"2,min=-10,max=10,res=0.01"`
+}
+
+// Packed is a positional struct whose fields are declared out of ordering number order
+type Packed struct {
+	_     struct{} `coachbuf:"positional"`
+	Count int32    `coachbuf:"2,min=0,max=15"`
+	Kind  int32    `coachbuf:"1,enc=gamma"`
+	Flags Header   `coachbuf:"3"`
+}
+
+// ErrSlowScout is returned by the Validate method of Unit
+var ErrSlowScout = errors.New("scout must have a speed of 1")
+
+// Unit holds constraint tags and a Validate method, both checked by the generated methods
+type Unit struct {
+	ID     int32                     `coachbuf:"1,min=0,max=1000,nonzero"`
+	Class  int32                     `coachbuf:"2,min=0,max=7,oneof=1|2|4"`
+	Speed  float32                   `coachbuf:"3,oneof=0.5|1"`
+	Target coachbuf.Optional[Vector] `coachbuf:"4,nonzero"`
+	Home   Header                    `coachbuf:"5,nonzero"`
+}
+
+// Validate requires a unit of class 1 to have a speed of 1
+func (u *Unit) Validate() error {
+	if u.Class == 1 && u.Speed != 1 {
+		return ErrSlowScout
+	}
+
+	return nil
+}
@@ -0,0 +1,762 @@
+// Code generated by coachbuf-gen; DO NOT EDIT.
+
+package gentest
+
+import (
+	"fmt"
+
+	"github.com/trphume/coachbuf"
+)
+
+// EncodeCoachbuf writes v in Coachbuf format, the output is identical to coachbuf.Encode
+func (v Player) EncodeCoachbuf(w coachbuf.BitWriter) error {
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=ID: %w", err)
+	}
+	if err := w.WriteInteger(v.ID, 0, 100000); err != nil {
+		return fmt.Errorf("field=ID: %w", err)
+	}
+	if err := w.WriteInteger(2, 0, 255); err != nil {
+		return fmt.Errorf("field=Health: %w", err)
+	}
+	if err := w.WriteInteger(int32(v.Health), 0, 100); err != nil {
+		return fmt.Errorf("field=Health: %w", err)
+	}
+	if err := w.WriteInteger(3, 0, 255); err != nil {
+		return fmt.Errorf("field=Score: %w", err)
+	}
+	if err := w.WriteVarInteger(v.Score); err != nil {
+		return fmt.Errorf("field=Score: %w", err)
+	}
+	if err := w.WriteInteger(4, 0, 255); err != nil {
+		return fmt.Errorf("field=Rank: %w", err)
+	}
+	if err := w.WriteGammaInteger(v.Rank); err != nil {
+		return fmt.Errorf("field=Rank: %w", err)
+	}
+	if err := w.WriteInteger(5, 0, 255); err != nil {
+		return fmt.Errorf("field=Default: %w", err)
+	}
+	if err := w.WriteInteger(v.Default, -2147483648, 2147483647); err != nil {
+		return fmt.Errorf("field=Default: %w", err)
+	}
+	if err := w.WriteInteger(6, 0, 255); err != nil {
+		return fmt.Errorf("field=Speed: %w", err)
+	}
+	if err := w.WriteFloat(v.Speed); err != nil {
+		return fmt.Errorf("field=Speed: %w", err)
+	}
+	if err := w.WriteInteger(7, 0, 255); err != nil {
+		return fmt.Errorf("field=IQ: %w", err)
+	}
+	if err := w.WriteCompressedFloat(real(v.IQ), -1, 1, 0.001); err != nil {
+		return fmt.Errorf("field=IQ: %w", err)
+	}
+	if err := w.WriteCompressedFloat(imag(v.IQ), -1, 1, 0.001); err != nil {
+		return fmt.Errorf("field=IQ: %w", err)
+	}
+	if err := w.WriteInteger(8, 0, 255); err != nil {
+		return fmt.Errorf("field=Wide: %w", err)
+	}
+	if err := w.WriteFloat64(real(v.Wide)); err != nil {
+		return fmt.Errorf("field=Wide: %w", err)
+	}
+	if err := w.WriteFloat64(imag(v.Wide)); err != nil {
+		return fmt.Errorf("field=Wide: %w", err)
+	}
+	if err := w.WriteInteger(9, 0, 255); err != nil {
+		return fmt.Errorf("field=Addr: %w", err)
+	}
+	if err := w.WriteAddr(v.Addr); err != nil {
+		return fmt.Errorf("field=Addr: %w", err)
+	}
+	if err := w.WriteInteger(10, 0, 255); err != nil {
+		return fmt.Errorf("field=Endpoint: %w", err)
+	}
+	if err := w.WriteAddrPort(v.Endpoint); err != nil {
+		return fmt.Errorf("field=Endpoint: %w", err)
+	}
+	if err := w.WriteInteger(11, 0, 255); err != nil {
+		return fmt.Errorf("field=Balance: %w", err)
+	}
+	if err := w.WriteBigInt(v.Balance, 128); err != nil {
+		return fmt.Errorf("field=Balance: %w", err)
+	}
+	if err := w.WriteInteger(12, 0, 255); err != nil {
+		return fmt.Errorf("field=Level: %w", err)
+	}
+	if err := w.WriteBool(v.Level.Valid); err != nil {
+		return fmt.Errorf("field=Level: %w", err)
+	}
+	if v.Level.Valid {
+		if err := w.WriteInteger(v.Level.Value, 1, 60); err != nil {
+			return fmt.Errorf("field=Level: %w", err)
+		}
+	}
+	if err := w.WriteInteger(13, 0, 255); err != nil {
+		return fmt.Errorf("field=Header: %w", err)
+	}
+	if err := w.WriteInteger(200, 0, 255); err != nil {
+		return fmt.Errorf("field=Header: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(v.Header.Kind, -4, 3); err != nil {
+		return fmt.Errorf("field=Header: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(0, 0, 255); err != nil {
+		return fmt.Errorf("field=Header: field=Version: %w", err)
+	}
+	if err := w.WriteVarInteger(v.Header.Version); err != nil {
+		return fmt.Errorf("field=Header: field=Version: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Header: field=Flags: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Header: field=Flags: field=Urgent: %w", err)
+	}
+	if err := w.WriteInteger(v.Header.Flags.Urgent, 0, 1); err != nil {
+		return fmt.Errorf("field=Header: field=Flags: field=Urgent: %w", err)
+	}
+	if err := w.WriteInteger(14, 0, 255); err != nil {
+		return fmt.Errorf("field=Position: %w", err)
+	}
+	if err := w.WriteBool(v.Position.Valid); err != nil {
+		return fmt.Errorf("field=Position: %w", err)
+	}
+	if v.Position.Valid {
+		if err := w.WriteInteger(1, 0, 255); err != nil {
+			return fmt.Errorf("field=Position: field=X: %w", err)
+		}
+		if err := w.WriteFloat(v.Position.Value.X); err != nil {
+			return fmt.Errorf("field=Position: field=X: %w", err)
+		}
+		if err := w.WriteInteger(2, 0, 255); err != nil {
+			return fmt.Errorf("field=Position: field=Y: %w", err)
+		}
+		if err := w.WriteCompressedFloat(float32(real(v.Position.Value.Y)), -10, 10, 0.01); err != nil {
+			return fmt.Errorf("field=Position: field=Y: %w", err)
+		}
+		if err := w.WriteCompressedFloat(float32(imag(v.Position.Value.Y)), -10, 10, 0.01); err != nil {
+			return fmt.Errorf("field=Position: field=Y: %w", err)
+		}
+	}
+	if err := w.WriteInteger(15, 0, 255); err != nil {
+		return fmt.Errorf("field=Packed: %w", err)
+	}
+	if err := w.WriteGammaInteger(v.Packed.Kind); err != nil {
+		return fmt.Errorf("field=Packed: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(v.Packed.Count, 0, 15); err != nil {
+		return fmt.Errorf("field=Packed: field=Count: %w", err)
+	}
+	if err := w.WriteInteger(200, 0, 255); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(v.Packed.Flags.Kind, -4, 3); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(0, 0, 255); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Version: %w", err)
+	}
+	if err := w.WriteVarInteger(v.Packed.Flags.Version); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Version: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Flags: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Flags: field=Urgent: %w", err)
+	}
+	if err := w.WriteInteger(v.Packed.Flags.Flags.Urgent, 0, 1); err != nil {
+		return fmt.Errorf("field=Packed: field=Flags: field=Flags: field=Urgent: %w", err)
+	}
+	return nil
+}
+
+// DecodeCoachbuf reads v in Coachbuf format, the input is read the same way as coachbuf.Decode
+func (v *Player) DecodeCoachbuf(r coachbuf.BitReader) error {
+	for i0 := 0; i0 < 15; i0++ {
+		order0, err := r.ReadInteger(0, 255)
+		if err != nil {
+			return fmt.Errorf("error reading ordering number: %w", err)
+		}
+		switch order0 {
+		case 1:
+			{
+				x, err := r.ReadInteger(0, 100000)
+				if err != nil {
+					return fmt.Errorf("field=ID: %w", err)
+				}
+				v.ID = x
+			}
+		case 2:
+			{
+				x, err := r.ReadInteger(0, 100)
+				if err != nil {
+					return fmt.Errorf("field=Health: %w", err)
+				}
+				v.Health = Health(x)
+			}
+		case 3:
+			{
+				x, err := r.ReadVarInteger()
+				if err != nil {
+					return fmt.Errorf("field=Score: %w", err)
+				}
+				v.Score = x
+			}
+		case 4:
+			{
+				x, err := r.ReadGammaInteger()
+				if err != nil {
+					return fmt.Errorf("field=Rank: %w", err)
+				}
+				v.Rank = x
+			}
+		case 5:
+			{
+				x, err := r.ReadInteger(-2147483648, 2147483647)
+				if err != nil {
+					return fmt.Errorf("field=Default: %w", err)
+				}
+				v.Default = x
+			}
+		case 6:
+			{
+				x, err := r.ReadFloat()
+				if err != nil {
+					return fmt.Errorf("field=Speed: %w", err)
+				}
+				v.Speed = x
+			}
+		case 7:
+			{
+				re, err := r.ReadCompressedFloat(-1, 1, 0.001)
+				if err != nil {
+					return fmt.Errorf("field=IQ: %w", err)
+				}
+				im, err := r.ReadCompressedFloat(-1, 1, 0.001)
+				if err != nil {
+					return fmt.Errorf("field=IQ: %w", err)
+				}
+				v.IQ = complex(re, im)
+			}
+		case 8:
+			{
+				re, err := r.ReadFloat64()
+				if err != nil {
+					return fmt.Errorf("field=Wide: %w", err)
+				}
+				im, err := r.ReadFloat64()
+				if err != nil {
+					return fmt.Errorf("field=Wide: %w", err)
+				}
+				v.Wide = complex(re, im)
+			}
+		case 9:
+			{
+				x, err := r.ReadAddr()
+				if err != nil {
+					return fmt.Errorf("field=Addr: %w", err)
+				}
+				v.Addr = x
+			}
+		case 10:
+			{
+				x, err := r.ReadAddrPort()
+				if err != nil {
+					return fmt.Errorf("field=Endpoint: %w", err)
+				}
+				v.Endpoint = x
+			}
+		case 11:
+			{
+				x, err := r.ReadBigInt(128)
+				if err != nil {
+					return fmt.Errorf("field=Balance: %w", err)
+				}
+				v.Balance = x
+			}
+		case 12:
+			{
+				present, err := r.ReadBool()
+				if err != nil {
+					return fmt.Errorf("field=Level: %w", err)
+				}
+				if !present {
+					v.Level = coachbuf.Optional[int32]{}
+				} else {
+					v.Level.Valid = true
+					{
+						x, err := r.ReadInteger(1, 60)
+						if err != nil {
+							return fmt.Errorf("field=Level: %w", err)
+						}
+						v.Level.Value = x
+					}
+				}
+			}
+		case 13:
+			for i1 := 0; i1 < 3; i1++ {
+				order1, err := r.ReadInteger(0, 255)
+				if err != nil {
+					return fmt.Errorf("field=Header: error reading ordering number: %w", err)
+				}
+				switch order1 {
+				case 200:
+					{
+						x, err := r.ReadInteger(-4, 3)
+						if err != nil {
+							return fmt.Errorf("field=Header: field=Kind: %w", err)
+						}
+						v.Header.Kind = x
+					}
+				case 0:
+					{
+						x, err := r.ReadVarInteger()
+						if err != nil {
+							return fmt.Errorf("field=Header: field=Version: %w", err)
+						}
+						v.Header.Version = x
+					}
+				case 1:
+					for i2 := 0; i2 < 1; i2++ {
+						order2, err := r.ReadInteger(0, 255)
+						if err != nil {
+							return fmt.Errorf("field=Header: field=Flags: error reading ordering number: %w", err)
+						}
+						switch order2 {
+						case 1:
+							{
+								x, err := r.ReadInteger(0, 1)
+								if err != nil {
+									return fmt.Errorf("field=Header: field=Flags: field=Urgent: %w", err)
+								}
+								v.Header.Flags.Urgent = x
+							}
+						default:
+							return fmt.Errorf("field=Header: field=Flags: order=%d is not used by type=struct{Urgent int32 \"coachbuf:\\\"1,min=0,max=1\\\"\"}", order2)
+						}
+					}
+				default:
+					return fmt.Errorf("field=Header: order=%d is not used by type=Header", order1)
+				}
+			}
+		case 14:
+			{
+				present, err := r.ReadBool()
+				if err != nil {
+					return fmt.Errorf("field=Position: %w", err)
+				}
+				if !present {
+					v.Position = coachbuf.Optional[Vector]{}
+				} else {
+					v.Position.Valid = true
+					for i1 := 0; i1 < 2; i1++ {
+						order1, err := r.ReadInteger(0, 255)
+						if err != nil {
+							return fmt.Errorf("field=Position: error reading ordering number: %w", err)
+						}
+						switch order1 {
+						case 1:
+							{
+								x, err := r.ReadFloat()
+								if err != nil {
+									return fmt.Errorf("field=Position: field=X: %w", err)
+								}
+								v.Position.Value.X = x
+							}
+						case 2:
+							{
+								re, err := r.ReadCompressedFloat(-10, 10, 0.01)
+								if err != nil {
+									return fmt.Errorf("field=Position: field=Y: %w", err)
+								}
+								im, err := r.ReadCompressedFloat(-10, 10, 0.01)
+								if err != nil {
+									return fmt.Errorf("field=Position: field=Y: %w", err)
+								}
+								v.Position.Value.Y = complex(float64(re), float64(im))
+							}
+						default:
+							return fmt.Errorf("field=Position: order=%d is not used by type=Vector", order1)
+						}
+					}
+				}
+			}
+		case 15:
+			{
+				x, err := r.ReadGammaInteger()
+				if err != nil {
+					return fmt.Errorf("field=Packed: field=Kind: %w", err)
+				}
+				v.Packed.Kind = x
+			}
+			{
+				x, err := r.ReadInteger(0, 15)
+				if err != nil {
+					return fmt.Errorf("field=Packed: field=Count: %w", err)
+				}
+				v.Packed.Count = x
+			}
+			for i1 := 0; i1 < 3; i1++ {
+				order1, err := r.ReadInteger(0, 255)
+				if err != nil {
+					return fmt.Errorf("field=Packed: field=Flags: error reading ordering number: %w", err)
+				}
+				switch order1 {
+				case 200:
+					{
+						x, err := r.ReadInteger(-4, 3)
+						if err != nil {
+							return fmt.Errorf("field=Packed: field=Flags: field=Kind: %w", err)
+						}
+						v.Packed.Flags.Kind = x
+					}
+				case 0:
+					{
+						x, err := r.ReadVarInteger()
+						if err != nil {
+							return fmt.Errorf("field=Packed: field=Flags: field=Version: %w", err)
+						}
+						v.Packed.Flags.Version = x
+					}
+				case 1:
+					for i2 := 0; i2 < 1; i2++ {
+						order2, err := r.ReadInteger(0, 255)
+						if err != nil {
+							return fmt.Errorf("field=Packed: field=Flags: field=Flags: error reading ordering number: %w", err)
+						}
+						switch order2 {
+						case 1:
+							{
+								x, err := r.ReadInteger(0, 1)
+								if err != nil {
+									return fmt.Errorf("field=Packed: field=Flags: field=Flags: field=Urgent: %w", err)
+								}
+								v.Packed.Flags.Flags.Urgent = x
+							}
+						default:
+							return fmt.Errorf("field=Packed: field=Flags: field=Flags: order=%d is not used by type=struct{Urgent int32 \"coachbuf:\\\"1,min=0,max=1\\\"\"}", order2)
+						}
+					}
+				default:
+					return fmt.Errorf("field=Packed: field=Flags: order=%d is not used by type=Header", order1)
+				}
+			}
+		default:
+			return fmt.Errorf("order=%d is not used by type=Player", order0)
+		}
+	}
+	return nil
+}
+
+// EncodeCoachbuf writes v in Coachbuf format, the output is identical to coachbuf.Encode
+func (v Header) EncodeCoachbuf(w coachbuf.BitWriter) error {
+	if err := w.WriteInteger(200, 0, 255); err != nil {
+		return fmt.Errorf("field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(v.Kind, -4, 3); err != nil {
+		return fmt.Errorf("field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(0, 0, 255); err != nil {
+		return fmt.Errorf("field=Version: %w", err)
+	}
+	if err := w.WriteVarInteger(v.Version); err != nil {
+		return fmt.Errorf("field=Version: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Flags: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Flags: field=Urgent: %w", err)
+	}
+	if err := w.WriteInteger(v.Flags.Urgent, 0, 1); err != nil {
+		return fmt.Errorf("field=Flags: field=Urgent: %w", err)
+	}
+	return nil
+}
+
+// DecodeCoachbuf reads v in Coachbuf format, the input is read the same way as coachbuf.Decode
+func (v *Header) DecodeCoachbuf(r coachbuf.BitReader) error {
+	for i0 := 0; i0 < 3; i0++ {
+		order0, err := r.ReadInteger(0, 255)
+		if err != nil {
+			return fmt.Errorf("error reading ordering number: %w", err)
+		}
+		switch order0 {
+		case 200:
+			{
+				x, err := r.ReadInteger(-4, 3)
+				if err != nil {
+					return fmt.Errorf("field=Kind: %w", err)
+				}
+				v.Kind = x
+			}
+		case 0:
+			{
+				x, err := r.ReadVarInteger()
+				if err != nil {
+					return fmt.Errorf("field=Version: %w", err)
+				}
+				v.Version = x
+			}
+		case 1:
+			for i1 := 0; i1 < 1; i1++ {
+				order1, err := r.ReadInteger(0, 255)
+				if err != nil {
+					return fmt.Errorf("field=Flags: error reading ordering number: %w", err)
+				}
+				switch order1 {
+				case 1:
+					{
+						x, err := r.ReadInteger(0, 1)
+						if err != nil {
+							return fmt.Errorf("field=Flags: field=Urgent: %w", err)
+						}
+						v.Flags.Urgent = x
+					}
+				default:
+					return fmt.Errorf("field=Flags: order=%d is not used by type=struct{Urgent int32 \"coachbuf:\\\"1,min=0,max=1\\\"\"}", order1)
+				}
+			}
+		default:
+			return fmt.Errorf("order=%d is not used by type=Header", order0)
+		}
+	}
+	return nil
+}
+
+// EncodeCoachbuf writes v in Coachbuf format, the output is identical to coachbuf.Encode
+func (v Unit) EncodeCoachbuf(w coachbuf.BitWriter) error {
+	if v.ID == 0 {
+		return fmt.Errorf("field=ID: value of type=int32 must be nonzero: %w", coachbuf.ErrConstraintViolated)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=ID: %w", err)
+	}
+	if err := w.WriteInteger(v.ID, 0, 1000); err != nil {
+		return fmt.Errorf("field=ID: %w", err)
+	}
+	if v.Class != 1 && v.Class != 2 && v.Class != 4 {
+		return fmt.Errorf("field=Class: value=%v must be one of [1 2 4]: %w", v.Class, coachbuf.ErrConstraintViolated)
+	}
+	if err := w.WriteInteger(2, 0, 255); err != nil {
+		return fmt.Errorf("field=Class: %w", err)
+	}
+	if err := w.WriteInteger(v.Class, 0, 7); err != nil {
+		return fmt.Errorf("field=Class: %w", err)
+	}
+	if v.Speed != 0.5 && v.Speed != 1 {
+		return fmt.Errorf("field=Speed: value=%v must be one of [0.5 1]: %w", v.Speed, coachbuf.ErrConstraintViolated)
+	}
+	if err := w.WriteInteger(3, 0, 255); err != nil {
+		return fmt.Errorf("field=Speed: %w", err)
+	}
+	if err := w.WriteFloat(v.Speed); err != nil {
+		return fmt.Errorf("field=Speed: %w", err)
+	}
+	if v.Target.Valid {
+		if v.Target.Value == (Vector{}) {
+			return fmt.Errorf("field=Target: value of type=Vector must be nonzero: %w", coachbuf.ErrConstraintViolated)
+		}
+	}
+	if err := w.WriteInteger(4, 0, 255); err != nil {
+		return fmt.Errorf("field=Target: %w", err)
+	}
+	if err := w.WriteBool(v.Target.Valid); err != nil {
+		return fmt.Errorf("field=Target: %w", err)
+	}
+	if v.Target.Valid {
+		if err := w.WriteInteger(1, 0, 255); err != nil {
+			return fmt.Errorf("field=Target: field=X: %w", err)
+		}
+		if err := w.WriteFloat(v.Target.Value.X); err != nil {
+			return fmt.Errorf("field=Target: field=X: %w", err)
+		}
+		if err := w.WriteInteger(2, 0, 255); err != nil {
+			return fmt.Errorf("field=Target: field=Y: %w", err)
+		}
+		if err := w.WriteCompressedFloat(float32(real(v.Target.Value.Y)), -10, 10, 0.01); err != nil {
+			return fmt.Errorf("field=Target: field=Y: %w", err)
+		}
+		if err := w.WriteCompressedFloat(float32(imag(v.Target.Value.Y)), -10, 10, 0.01); err != nil {
+			return fmt.Errorf("field=Target: field=Y: %w", err)
+		}
+	}
+	if v.Home == (Header{}) {
+		return fmt.Errorf("field=Home: value of type=Header must be nonzero: %w", coachbuf.ErrConstraintViolated)
+	}
+	if err := w.WriteInteger(5, 0, 255); err != nil {
+		return fmt.Errorf("field=Home: %w", err)
+	}
+	if err := w.WriteInteger(200, 0, 255); err != nil {
+		return fmt.Errorf("field=Home: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(v.Home.Kind, -4, 3); err != nil {
+		return fmt.Errorf("field=Home: field=Kind: %w", err)
+	}
+	if err := w.WriteInteger(0, 0, 255); err != nil {
+		return fmt.Errorf("field=Home: field=Version: %w", err)
+	}
+	if err := w.WriteVarInteger(v.Home.Version); err != nil {
+		return fmt.Errorf("field=Home: field=Version: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Home: field=Flags: %w", err)
+	}
+	if err := w.WriteInteger(1, 0, 255); err != nil {
+		return fmt.Errorf("field=Home: field=Flags: field=Urgent: %w", err)
+	}
+	if err := w.WriteInteger(v.Home.Flags.Urgent, 0, 1); err != nil {
+		return fmt.Errorf("field=Home: field=Flags: field=Urgent: %w", err)
+	}
+	return nil
+}
+
+// DecodeCoachbuf reads v in Coachbuf format, the input is read the same way as coachbuf.Decode
+func (v *Unit) DecodeCoachbuf(r coachbuf.BitReader) error {
+	for i0 := 0; i0 < 5; i0++ {
+		order0, err := r.ReadInteger(0, 255)
+		if err != nil {
+			return fmt.Errorf("error reading ordering number: %w", err)
+		}
+		switch order0 {
+		case 1:
+			{
+				x, err := r.ReadInteger(0, 1000)
+				if err != nil {
+					return fmt.Errorf("field=ID: %w", err)
+				}
+				v.ID = x
+			}
+			if v.ID == 0 {
+				return fmt.Errorf("field=ID: value of type=int32 must be nonzero: %w", coachbuf.ErrConstraintViolated)
+			}
+		case 2:
+			{
+				x, err := r.ReadInteger(0, 7)
+				if err != nil {
+					return fmt.Errorf("field=Class: %w", err)
+				}
+				v.Class = x
+			}
+			if v.Class != 1 && v.Class != 2 && v.Class != 4 {
+				return fmt.Errorf("field=Class: value=%v must be one of [1 2 4]: %w", v.Class, coachbuf.ErrConstraintViolated)
+			}
+		case 3:
+			{
+				x, err := r.ReadFloat()
+				if err != nil {
+					return fmt.Errorf("field=Speed: %w", err)
+				}
+				v.Speed = x
+			}
+			if v.Speed != 0.5 && v.Speed != 1 {
+				return fmt.Errorf("field=Speed: value=%v must be one of [0.5 1]: %w", v.Speed, coachbuf.ErrConstraintViolated)
+			}
+		case 4:
+			{
+				present, err := r.ReadBool()
+				if err != nil {
+					return fmt.Errorf("field=Target: %w", err)
+				}
+				if !present {
+					v.Target = coachbuf.Optional[Vector]{}
+				} else {
+					v.Target.Valid = true
+					for i1 := 0; i1 < 2; i1++ {
+						order1, err := r.ReadInteger(0, 255)
+						if err != nil {
+							return fmt.Errorf("field=Target: error reading ordering number: %w", err)
+						}
+						switch order1 {
+						case 1:
+							{
+								x, err := r.ReadFloat()
+								if err != nil {
+									return fmt.Errorf("field=Target: field=X: %w", err)
+								}
+								v.Target.Value.X = x
+							}
+						case 2:
+							{
+								re, err := r.ReadCompressedFloat(-10, 10, 0.01)
+								if err != nil {
+									return fmt.Errorf("field=Target: field=Y: %w", err)
+								}
+								im, err := r.ReadCompressedFloat(-10, 10, 0.01)
+								if err != nil {
+									return fmt.Errorf("field=Target: field=Y: %w", err)
+								}
+								v.Target.Value.Y = complex(float64(re), float64(im))
+							}
+						default:
+							return fmt.Errorf("field=Target: order=%d is not used by type=Vector", order1)
+						}
+					}
+				}
+			}
+			if v.Target.Valid {
+				if v.Target.Value == (Vector{}) {
+					return fmt.Errorf("field=Target: value of type=Vector must be nonzero: %w", coachbuf.ErrConstraintViolated)
+				}
+			}
+		case 5:
+			for i1 := 0; i1 < 3; i1++ {
+				order1, err := r.ReadInteger(0, 255)
+				if err != nil {
+					return fmt.Errorf("field=Home: error reading ordering number: %w", err)
+				}
+				switch order1 {
+				case 200:
+					{
+						x, err := r.ReadInteger(-4, 3)
+						if err != nil {
+							return fmt.Errorf("field=Home: field=Kind: %w", err)
+						}
+						v.Home.Kind = x
+					}
+				case 0:
+					{
+						x, err := r.ReadVarInteger()
+						if err != nil {
+							return fmt.Errorf("field=Home: field=Version: %w", err)
+						}
+						v.Home.Version = x
+					}
+				case 1:
+					for i2 := 0; i2 < 1; i2++ {
+						order2, err := r.ReadInteger(0, 255)
+						if err != nil {
+							return fmt.Errorf("field=Home: field=Flags: error reading ordering number: %w", err)
+						}
+						switch order2 {
+						case 1:
+							{
+								x, err := r.ReadInteger(0, 1)
+								if err != nil {
+									return fmt.Errorf("field=Home: field=Flags: field=Urgent: %w", err)
+								}
+								v.Home.Flags.Urgent = x
+							}
+						default:
+							return fmt.Errorf("field=Home: field=Flags: order=%d is not used by type=struct{Urgent int32 \"coachbuf:\\\"1,min=0,max=1\\\"\"}", order2)
+						}
+					}
+				default:
+					return fmt.Errorf("field=Home: order=%d is not used by type=Header", order1)
+				}
+			}
+			if v.Home == (Header{}) {
+				return fmt.Errorf("field=Home: value of type=Header must be nonzero: %w", coachbuf.ErrConstraintViolated)
+			}
+		default:
+			return fmt.Errorf("order=%d is not used by type=Unit", order0)
+		}
+	}
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("type=Unit failed validation: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package gentest_test
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+	"github.com/trphume/coachbuf/internal/gentest"
+)
+
+func testPlayers() []gentest.Player {
+	full := gentest.Player{
+		ID:       99999,
+		Health:   42,
+		Score:    -123456,
+		Rank:     7,
+		Default:  -1,
+		Speed:    3.75,
+		IQ:       complex(0.25, -0.5),
+		Wide:     complex(1e100, -1e-100),
+		Addr:     netip.MustParseAddr("2001:db8::68"),
+		Endpoint: netip.MustParseAddrPort("127.0.0.1:65535"),
+		Balance:  new(big.Int).Lsh(big.NewInt(-1), 100),
+		Level:    coachbuf.Some[int32](60),
+		Position: coachbuf.Some(gentest.Vector{X: -1.5, Y: complex(9.99, -10)}),
+	}
+	full.Header.Kind = -4
+	full.Header.Version = 1 << 20
+	full.Header.Flags.Urgent = 1
+	full.Packed.Count = 15
+	full.Packed.Kind = -9
+	full.Packed.Flags.Kind = 3
+
+	empty := gentest.Player{
+		Addr:     netip.MustParseAddr("0.0.0.0"),
+		Endpoint: netip.MustParseAddrPort("[::]:0"),
+		Balance:  big.NewInt(0),
+	}
+
+	return []gentest.Player{full, empty}
+}
+
+// TestGeneratedEncode enforces that generated methods write byte-identical output to the reflection path
+func TestGeneratedEncode(t *testing.T) {
+	for i, input := range testPlayers() {
+		want, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		w := coachbuf.NewBitWriter()
+		if err := input.EncodeCoachbuf(w); err != nil {
+			t.Fatalf("EncodeCoachbuf() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() = %v, want %v", err.Error(), nil)
+		}
+
+		if result := w.Bytes(); string(result) != string(want) {
+			t.Errorf("player %d EncodeCoachbuf() = %v, want %v", i, result, want)
+		}
+	}
+}
+
+// TestGeneratedDecode enforces that generated methods read the same values as the reflection path
+func TestGeneratedDecode(t *testing.T) {
+	for i, input := range testPlayers() {
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var want gentest.Player
+		if err := coachbuf.Decode(data, &want); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result gentest.Player
+		if err := result.DecodeCoachbuf(coachbuf.NewBitReader(data)); err != nil {
+			t.Fatalf("DecodeCoachbuf() = %v, want %v", err.Error(), nil)
+		}
+
+		if !reflect.DeepEqual(result, want) {
+			t.Errorf("player %d DecodeCoachbuf() = %+v, want %+v", i, result, want)
+		}
+	}
+}
+
+// uncheckedUnit has the wire format of gentest.Unit without its constraint tags and Validate method
+type uncheckedUnit struct {
+	ID     int32                             `coachbuf:"1,min=0,max=1000"`
+	Class  int32                             `coachbuf:"2,min=0,max=7"`
+	Speed  float32                           `coachbuf:"3"`
+	Target coachbuf.Optional[gentest.Vector] `coachbuf:"4"`
+	Home   gentest.Header                    `coachbuf:"5"`
+}
+
+// TestGeneratedConstraints enforces that generated methods reject the same values as the reflection path
+func TestGeneratedConstraints(t *testing.T) {
+	valid := uncheckedUnit{ID: 1, Class: 1, Speed: 1, Home: gentest.Header{Kind: 1}}
+
+	tests := []struct {
+		name   string
+		modify func(u *uncheckedUnit)
+		err    error
+	}{
+		{name: "valid", modify: func(u *uncheckedUnit) {}, err: nil},
+		{name: "nonzero int32", modify: func(u *uncheckedUnit) { u.ID = 0 }, err: coachbuf.ErrConstraintViolated},
+		{name: "oneof int32", modify: func(u *uncheckedUnit) { u.Class = 3 }, err: coachbuf.ErrConstraintViolated},
+		{name: "oneof float32", modify: func(u *uncheckedUnit) { u.Speed = 0.25 }, err: coachbuf.ErrConstraintViolated},
+		{name: "nonzero present Optional", modify: func(u *uncheckedUnit) { u.Target = coachbuf.Some(gentest.Vector{}) }, err: coachbuf.ErrConstraintViolated},
+		{name: "nonzero struct", modify: func(u *uncheckedUnit) { u.Home = gentest.Header{} }, err: coachbuf.ErrConstraintViolated},
+		{name: "Validate", modify: func(u *uncheckedUnit) { u.Speed = 0.5 }, err: gentest.ErrSlowScout},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			unchecked := valid
+			tt.modify(&unchecked)
+			data, err := coachbuf.Encode(unchecked)
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			var want, result gentest.Unit
+			if err := coachbuf.Decode(data, &want); !errors.Is(err, tt.err) {
+				t.Errorf("Decode() = %v, want %v", err, tt.err)
+			}
+			if err := result.DecodeCoachbuf(coachbuf.NewBitReader(data)); !errors.Is(err, tt.err) {
+				t.Errorf("DecodeCoachbuf() = %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(result, want) {
+				t.Errorf("DecodeCoachbuf() = %+v, want %+v", result, want)
+			}
+
+			// Validate is only called when decoding
+			encodeErr := tt.err
+			if encodeErr == gentest.ErrSlowScout {
+				encodeErr = nil
+			}
+			input := gentest.Unit(unchecked)
+			if _, err := coachbuf.Encode(input); !errors.Is(err, encodeErr) {
+				t.Errorf("Encode() = %v, want %v", err, encodeErr)
+			}
+			if err := input.EncodeCoachbuf(coachbuf.NewBitWriter()); !errors.Is(err, encodeErr) {
+				t.Errorf("EncodeCoachbuf() = %v, want %v", err, encodeErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package coachbuf
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// Marshaler is implemented by types that write their own Coachbuf representation
+//
+// tag holds the values of the coachbuf struct tag of the field being encoded, it is empty for top level values
+type Marshaler interface {
+	MarshalCoachbuf(w BitWriter, tag Tag) error
+}
+
+// Unmarshaler is implemented by types that read their own Coachbuf representation written by their Marshaler
+//
+// tag holds the values of the coachbuf struct tag of the field being decoded, it is empty for top level values
+type Unmarshaler interface {
+	UnmarshalCoachbuf(r BitReader, tag Tag) error
+}
+
+// Stream writes or reads values through the same serialize calls, see Serializer
+type Stream = coachwire.Stream
+
+// Serializer is implemented by types describing their Coachbuf representation once for both encoding and decoding
+//
+// SerializeCoachbuf is called with a writing Stream when encoding and a reading Stream when decoding,
+// so the method must have a pointer receiver. tag holds the values of the coachbuf struct tag of the field
+type Serializer interface {
+	SerializeCoachbuf(s Stream, tag Tag) error
+}
+
+// Tag holds the values of a coachbuf struct tag following the ordering number, such as "min=0" or "enc=varint"
+type Tag []string
+
+// Lookup returns the value of a key=value tag and whether the key was found
+func (t Tag) Lookup(key string) (string, bool) {
+	return lookupTag(t, key)
+}
+
+var (
+	marshalerType   = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	serializerType  = reflect.TypeOf((*Serializer)(nil)).Elem()
+
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// compileMarshaler returns the codec calling the Marshaler and Unmarshaler methods of a type
+// the returned bool is false when the type implements neither interface
+func compileMarshaler(rt reflect.Type, tags []string) (codec, bool, error) {
+	pointerType := reflect.PointerTo(rt)
+	isMarshaler := rt.Implements(marshalerType) || pointerType.Implements(marshalerType)
+	isUnmarshaler := pointerType.Implements(unmarshalerType) || (rt.Kind() == reflect.Pointer && rt.Implements(unmarshalerType))
+
+	switch {
+	case !isMarshaler && !isUnmarshaler:
+		return compileSerializer(rt, tags)
+	case !isMarshaler || !isUnmarshaler:
+		return codec{}, true, fmt.Errorf("type=%v must implement both Marshaler and Unmarshaler: %w", rt, ErrUnsupportedType)
+	}
+	if err := checkPromoted(rt, marshalerType, unmarshalerType); err != nil {
+		return codec{}, true, err
+	}
+
+	info := codecInfo{encoding: EncodingCustom, maxBits: unboundedBits}
+	decode := unmarshalerDecoder(tagOf(tags))
+	return codec{encode: marshalerEncoder(tagOf(tags)), decode: decode, skip: scratchSkipper(rt, decode), info: info}, true, nil
+}
+
+// compileSerializer returns the codec calling the SerializeCoachbuf method of a type
+// the returned bool is false when the type does not implement Serializer
+func compileSerializer(rt reflect.Type, tags []string) (codec, bool, error) {
+	if !reflect.PointerTo(rt).Implements(serializerType) && !(rt.Kind() == reflect.Pointer && rt.Implements(serializerType)) {
+		return codec{}, false, nil
+	}
+	if err := checkPromoted(rt, serializerType); err != nil {
+		return codec{}, true, err
+	}
+
+	info := codecInfo{encoding: EncodingCustom, maxBits: unboundedBits}
+	decode := serializerDecoder(tagOf(tags))
+	return codec{encode: serializerEncoder(tagOf(tags)), decode: decode, skip: scratchSkipper(rt, decode), info: info}, true, nil
+}
+
+// compileBlob returns the codec writing the bytes of encoding.BinaryMarshaler, or otherwise encoding.TextMarshaler,
+// as a blob of at most maxlen bytes. It is the fallback for types without native support
+// the returned bool is false when the type implements neither interface
+func compileBlob(rt reflect.Type, tags []string) (codec, bool, error) {
+	isBinary := implementsPair(rt, binaryMarshalerType, binaryUnmarshalerType)
+	isText := implementsPair(rt, textMarshalerType, textUnmarshalerType)
+	if !isBinary && !isText {
+		if implementsAny(rt, binaryMarshalerType, binaryUnmarshalerType, textMarshalerType, textUnmarshalerType) {
+			return codec{}, true, fmt.Errorf("type=%v must implement both halves of encoding.BinaryMarshaler or encoding.TextMarshaler: %w", rt, ErrUnsupportedType)
+		}
+		return codec{}, false, nil
+	}
+
+	maxLen, err := getMaxLenTag(tags)
+	if err != nil {
+		return codec{}, true, err
+	}
+
+	info := codecInfo{encoding: EncodingBlob, max: float64(maxLen)}
+	info.minBits, info.maxBits = coachwire.BytesBits(maxLen)
+	return codec{encode: blobEncoder(!isBinary, maxLen), decode: blobDecoder(!isBinary, maxLen), skip: blobSkipper(maxLen), info: info}, true, nil
+}
+
+// implementsPair reports whether values of a type can be marshaled with marshaler and decoded into with unmarshaler
+func implementsPair(rt, marshaler, unmarshaler reflect.Type) bool {
+	pointerType := reflect.PointerTo(rt)
+	return (rt.Implements(marshaler) || pointerType.Implements(marshaler)) &&
+		(pointerType.Implements(unmarshaler) || (rt.Kind() == reflect.Pointer && rt.Implements(unmarshaler)))
+}
+
+// implementsAny reports whether a type or a pointer to it implements any of the interface types
+func implementsAny(rt reflect.Type, ifaces ...reflect.Type) bool {
+	pointerType := reflect.PointerTo(rt)
+	for _, iface := range ifaces {
+		if rt.Implements(iface) || pointerType.Implements(iface) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasTaggedFields reports whether any field of a struct type has a coachbuf tag
+// structs without tagged fields, such as time.Time, fall back to their encoding.BinaryMarshaler
+func hasTaggedFields(rt reflect.Type) bool {
+	for i := 0; i < rt.NumField(); i++ {
+		if fieldTag(rt, rt.Field(i)) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkPromoted returns an error when a struct with tagged fields embeds a field implementing one of the interface
+// types, the methods promoted from the embedded field would be called instead of encoding the tagged fields
+func checkPromoted(rt reflect.Type, ifaces ...reflect.Type) error {
+	if rt.Kind() != reflect.Struct || !hasTaggedFields(rt) {
+		return nil
+	}
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && implementsAny(field.Type, ifaces...) {
+			return fmt.Errorf("type=%v has tagged fields and methods promoted from embedded field=%s: %w", rt, field.Name, ErrUnsupportedType)
+		}
+	}
+
+	return nil
+}
+
+// tagOf returns the values of a struct tag following the ordering number
+func tagOf(tags []string) Tag {
+	if len(tags) > 1 {
+		return tags[1:]
+	}
+
+	return nil
+}
+
+// implementsMarshaler reports whether a type implements either Marshaler, Unmarshaler or Serializer
+func implementsMarshaler(rt reflect.Type) bool {
+	pointerType := reflect.PointerTo(rt)
+	return rt.Implements(marshalerType) || pointerType.Implements(marshalerType) ||
+		rt.Implements(unmarshalerType) || pointerType.Implements(unmarshalerType) ||
+		pointerType.Implements(serializerType)
+}
@@ -0,0 +1,334 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/trphume/coachbuf"
+)
+
+// Heading is an angle in degrees packed in as many bits as the bits tag, default 9
+type Heading float32
+
+func (h Heading) MarshalCoachbuf(w coachbuf.BitWriter, tag coachbuf.Tag) error {
+	bits, err := headingBits(tag)
+	if err != nil {
+		return err
+	}
+
+	return w.WriteBits(uint32(float32(h)/360*float32(uint32(1)<<bits-1)+0.5), bits)
+}
+
+func (h *Heading) UnmarshalCoachbuf(r coachbuf.BitReader, tag coachbuf.Tag) error {
+	bits, err := headingBits(tag)
+	if err != nil {
+		return err
+	}
+
+	value, err := r.ReadBits(bits)
+	if err != nil {
+		return err
+	}
+	*h = Heading(float32(value) * 360 / float32(uint32(1)<<bits-1))
+
+	return nil
+}
+
+func headingBits(tag coachbuf.Tag) (int, error) {
+	value, ok := tag.Lookup("bits")
+	if !ok {
+		return 9, nil
+	}
+
+	return strconv.Atoi(value)
+}
+
+// Version is a pointer type implementing both interfaces with a pointer receiver
+type Version struct {
+	Major, Minor int32
+}
+
+func (v *Version) MarshalCoachbuf(w coachbuf.BitWriter, _ coachbuf.Tag) error {
+	if err := w.WriteVarInteger(v.Major); err != nil {
+		return err
+	}
+
+	return w.WriteVarInteger(v.Minor)
+}
+
+func (v *Version) UnmarshalCoachbuf(r coachbuf.BitReader, _ coachbuf.Tag) error {
+	var err error
+	if v.Major, err = r.ReadVarInteger(); err != nil {
+		return err
+	}
+	v.Minor, err = r.ReadVarInteger()
+
+	return err
+}
+
+// EncodeOnly implements Marshaler without Unmarshaler
+type EncodeOnly int32
+
+func (EncodeOnly) MarshalCoachbuf(coachbuf.BitWriter, coachbuf.Tag) error { return nil }
+
+func TestMarshaler(t *testing.T) {
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		type Ship struct {
+			Heading  Heading  `coachbuf:"1"`
+			Turret   Heading  `coachbuf:"2,bits=4"`
+			Version  Version  `coachbuf:"3"`
+			Previous *Version `coachbuf:"4"`
+			Health   int32    `coachbuf:"5,min=0,max=100"`
+		}
+
+		input := Ship{Heading: 360, Turret: 0, Version: Version{Major: 1, Minor: 2}, Previous: &Version{Major: 1}, Health: 42}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		// 5 orderings of 8 bits, 9 + 4 bits of headings, 2 varints of 5 bits each twice, 7 bits of health
+		if want := 4 * 3; len(data) != want {
+			t.Errorf("Encode() = %v bytes, want %v bytes", len(data), want)
+		}
+
+		var result Ship
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if result.Heading != input.Heading || result.Turret != input.Turret || result.Version != input.Version ||
+			result.Previous == nil || *result.Previous != *input.Previous || result.Health != input.Health {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("top level value", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := coachbuf.Encode(Version{Major: 3, Minor: 14})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Version
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if want := (Version{Major: 3, Minor: 14}); result != want {
+			t.Errorf("Decode() = %v, want %v", result, want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		type MissingUnmarshaler struct {
+			Value EncodeOnly `coachbuf:"1"`
+		}
+		type NilPointer struct {
+			Version *Version `coachbuf:"1"`
+		}
+		// the methods of Heading are promoted to Promoted, they would be called instead of encoding Y
+		type Promoted struct {
+			Heading
+			Y int32 `coachbuf:"2,min=0,max=1000"`
+		}
+		type NestedPromoted struct {
+			Value Promoted `coachbuf:"1"`
+		}
+
+		tests := []struct {
+			name  string
+			input any
+		}{
+			{name: "missing Unmarshaler", input: MissingUnmarshaler{}},
+			{name: "nil pointer", input: NilPointer{}},
+			{name: "methods promoted to a struct with tagged fields", input: Promoted{Y: 3}},
+			{name: "nested methods promoted to a struct with tagged fields", input: NestedPromoted{}},
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				if _, err := coachbuf.Encode(tt.input); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+					t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+				}
+			})
+		}
+	})
+}
+
+// Position describes its wire format once for both directions, quantized with the res tag when present
+type Position struct {
+	X, Y float32
+}
+
+func (p *Position) SerializeCoachbuf(s coachbuf.Stream, tag coachbuf.Tag) error {
+	if _, ok := tag.Lookup("res"); ok {
+		if err := s.SerializeCompressedFloat(&p.X, -100, 100, 0.5); err != nil {
+			return err
+		}
+		return s.SerializeCompressedFloat(&p.Y, -100, 100, 0.5)
+	}
+
+	if err := s.SerializeFloat(&p.X); err != nil {
+		return err
+	}
+	return s.SerializeFloat(&p.Y)
+}
+
+func TestSerializer(t *testing.T) {
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		type Unit struct {
+			Position Position  `coachbuf:"1"`
+			Target   *Position `coachbuf:"2,res=0.5"`
+			Health   int32     `coachbuf:"3,min=0,max=100"`
+		}
+
+		input := Unit{Position: Position{X: 1.125, Y: -3}, Target: &Position{X: 50.5, Y: -20}, Health: 99}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Unit
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if result.Position != input.Position || result.Target == nil || *result.Target != *input.Target || result.Health != input.Health {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("method promoted to a struct with tagged fields", func(t *testing.T) {
+		t.Parallel()
+
+		type Promoted struct {
+			Position
+			Health int32 `coachbuf:"1,min=0,max=100"`
+		}
+
+		if _, err := coachbuf.Encode(Promoted{Health: 3}); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+		}
+		if err := coachbuf.Decode(make([]byte, 4), &Promoted{}); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+		}
+	})
+
+	t.Run("BitWriter and BitReader Stream", func(t *testing.T) {
+		t.Parallel()
+
+		input := Position{X: 7, Y: 8}
+		w := coachbuf.NewBitWriter()
+		if err := input.SerializeCoachbuf(w.Stream(), nil); err != nil {
+			t.Fatalf("SerializeCoachbuf() = %v, want %v", err.Error(), nil)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush() = %v, want %v", err.Error(), nil)
+		}
+
+		// output must be identical to Encode of the same top level value
+		want, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if string(w.Bytes()) != string(want) {
+			t.Errorf("Bytes() = %v, want %v", w.Bytes(), want)
+		}
+
+		var result Position
+		if err := result.SerializeCoachbuf(coachbuf.NewBitReader(w.Bytes()).Stream(), nil); err != nil {
+			t.Fatalf("SerializeCoachbuf() = %v, want %v", err.Error(), nil)
+		}
+		if result != input {
+			t.Errorf("SerializeCoachbuf() = %v, want %v", result, input)
+		}
+	})
+}
+
+// Label implements encoding.TextMarshaler only
+type Label string
+
+func (l Label) MarshalText() ([]byte, error) { return []byte("label:" + l), nil }
+
+func (l *Label) UnmarshalText(text []byte) error {
+	*l = Label(text[len("label:"):])
+	return nil
+}
+
+func TestBlobFallback(t *testing.T) {
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		type Event struct {
+			At    time.Time `coachbuf:"1"`
+			IP    net.IP    `coachbuf:"2,maxlen=64"`
+			Label Label     `coachbuf:"3,maxlen=16"`
+			Addr  netip.Addr
+		}
+
+		input := Event{
+			At:    time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+			IP:    net.ParseIP("192.168.1.1"),
+			Label: "boss",
+		}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Event
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if !result.At.Equal(input.At) || !result.IP.Equal(input.IP) || result.Label != input.Label {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("native types take precedence", func(t *testing.T) {
+		t.Parallel()
+
+		// netip.Addr implements encoding.BinaryMarshaler but is written as a discriminator and 32 bits
+		type Native struct {
+			Addr netip.Addr `coachbuf:"1"`
+		}
+
+		data, err := coachbuf.Encode(Native{Addr: netip.MustParseAddr("10.0.0.1")})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if want := 8; len(data) != want {
+			t.Errorf("Encode() = %v bytes, want %v bytes", len(data), want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		type TooLong struct {
+			Label Label `coachbuf:"1,maxlen=4"`
+		}
+		type InvalidMaxLen struct {
+			Label Label `coachbuf:"1,maxlen=0"`
+		}
+
+		if _, err := coachbuf.Encode(TooLong{Label: "boss"}); err == nil {
+			t.Errorf("Encode() = %v, want an error", err)
+		}
+		if _, err := coachbuf.Encode(InvalidMaxLen{}); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+	})
+}
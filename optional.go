@@ -0,0 +1,70 @@
+package coachbuf
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Optional holds a value that may be absent without requiring a pointer
+//
+// It is encoded as a single presence bit followed by the value when present,
+// tags given to an Optional struct field apply to the wrapped value
+type Optional[T any] struct {
+	Value T
+	Valid bool
+}
+
+// Some returns an Optional holding the given value
+func Some[T any](v T) Optional[T] {
+	return Optional[T]{Value: v, Valid: true}
+}
+
+// None returns an Optional without a value
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// Get returns the value and whether it is present
+func (o Optional[T]) Get() (T, bool) {
+	return o.Value, o.Valid
+}
+
+// MarshalJSON encodes the value when present, otherwise null
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.Valid {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(o.Value)
+}
+
+// UnmarshalJSON decodes null as an absent value, otherwise the value is decoded and marked present
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = Optional[T]{}
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Some(v)
+
+	return nil
+}
+
+// optionalPkgPath is the package path shared by every instantiation of Optional
+var optionalPkgPath = reflect.TypeOf(Optional[int32]{}).PkgPath()
+
+// isOptional reports whether rt is an instantiation of Optional, a struct embedding an Optional is not one
+func isOptional(rt reflect.Type) bool {
+	return rt.Kind() == reflect.Struct && rt.PkgPath() == optionalPkgPath && strings.HasPrefix(rt.Name(), "Optional[")
+}
+
+// index of Optional struct fields used when encoding and decoding via reflection
+const (
+	optionalValueField = 0
+	optionalValidField = 1
+)
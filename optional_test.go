@@ -0,0 +1,104 @@
+package coachbuf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+func TestOptional(t *testing.T) {
+	type TestStruct struct {
+		Present coachbuf.Optional[int32]   `coachbuf:"1,min=0,max=100"`
+		Absent  coachbuf.Optional[float32] `coachbuf:"2"`
+		Nested  coachbuf.Optional[struct {
+			Int32 int32 `coachbuf:"1,enc=varint"`
+		}] `coachbuf:"3"`
+	}
+
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		inputEncode := TestStruct{Present: coachbuf.Some[int32](42), Absent: coachbuf.None[float32]()}
+		inputEncode.Nested = coachbuf.Some(struct {
+			Int32 int32 `coachbuf:"1,enc=varint"`
+		}{Int32: -7})
+
+		inputDecode, err := coachbuf.Encode(inputEncode)
+		if err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		// absent values must reset any previous value of the target
+		result := TestStruct{Absent: coachbuf.Some[float32](1.5)}
+		if err := coachbuf.Decode(inputDecode, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if result != inputEncode {
+			t.Errorf("Decode() = %v, want %v", result, inputEncode)
+		}
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		t.Parallel()
+
+		if v, ok := coachbuf.Some[int32](5).Get(); !ok || v != 5 {
+			t.Errorf("Get() = %v %v, want %v %v", v, ok, 5, true)
+		}
+		if v, ok := coachbuf.None[int32]().Get(); ok || v != 0 {
+			t.Errorf("Get() = %v %v, want %v %v", v, ok, 0, false)
+		}
+	})
+
+	t.Run("MarshalJSON and UnmarshalJSON", func(t *testing.T) {
+		t.Parallel()
+
+		type JSONStruct struct {
+			Present coachbuf.Optional[int32] `json:"present"`
+			Absent  coachbuf.Optional[int32] `json:"absent"`
+		}
+
+		input := JSONStruct{Present: coachbuf.Some[int32](10)}
+		want := `{"present":10,"absent":null}`
+
+		data, err := json.Marshal(input)
+		if err != nil {
+			t.Errorf("Marshal() = %v, want %v", err.Error(), nil)
+		}
+		if string(data) != want {
+			t.Errorf("Marshal() = %s, want %s", data, want)
+		}
+
+		var result JSONStruct
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Errorf("Unmarshal() = %v, want %v", err.Error(), nil)
+		}
+		if result != input {
+			t.Errorf("Unmarshal() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("struct embedding Optional", func(t *testing.T) {
+		t.Parallel()
+
+		// the promoted methods of the embedded Optional do not make the struct an Optional
+		type Embedding struct {
+			coachbuf.Optional[int32] `coachbuf:"1,min=0,max=10"`
+			Count                    int32 `coachbuf:"2,min=0,max=100"`
+		}
+
+		input := Embedding{Optional: coachbuf.Some[int32](7), Count: 42}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Embedding
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+}
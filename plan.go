@@ -0,0 +1,376 @@
+package coachbuf
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// encoderFunc writes a value of the type it was compiled for
+type encoderFunc func(e *encodeState, rv reflect.Value) error
+
+// decoderFunc reads a value of the type it was compiled for into the settable rv
+type decoderFunc func(d *decodeState, rv reflect.Value) error
+
+// skipperFunc reads past a value of the type it was compiled for without assigning it, see DecodeFields
+type skipperFunc func(d *decodeState) error
+
+// codec is the compiled encoder, decoder and skipper of a type given the tags of the field holding it
+type codec struct {
+	encode encoderFunc
+	decode decoderFunc
+	skip   skipperFunc
+	info   codecInfo
+}
+
+// codecInfo describes the wire representation selected by a codec, see SchemaOf
+type codecInfo struct {
+	encoding      Encoding
+	min, max, res float64
+	optional      bool
+	plan          *structPlan // only set for nested struct types
+
+	// minBits and maxBits bound the number of bits of a value, maxBits is unboundedBits when unknown
+	minBits, maxBits int
+}
+
+// structPlan is the compiled form of a struct type
+// struct tags are parsed and validated once when the plan is built
+type structPlan struct {
+	typ    reflect.Type
+	fields []fieldPlan // tagged fields in declaration order
+	opts   structOptions
+
+	// byOrder holds the indexes of fields sorted by ordering number, the order fields are written in positional mode
+	byOrder []int
+
+	// validator is set when a pointer to the struct implements Validator
+	validator bool
+
+	// unknown is the index of the Unknown field of the struct, noUnknownField when there is none
+	unknown int
+
+	// orderToField maps an ordering number to its index in fields plus one, zero means the ordering is not used
+	orderToField [cbMaxOrderingNumber + 1]int
+}
+
+// fieldPlan is the compiled form of a tagged struct field
+type fieldPlan struct {
+	name  string
+	index int
+	order int32
+	tags  []string
+	codec codec
+	check checkFunc // constraint tags of the field, nil when there are none
+}
+
+// typeCodec is the cached result of compiling a type, compile errors are cached as well so they are reported once
+type typeCodec struct {
+	codec       codec
+	plan        *structPlan // only set for struct types
+	fingerprint uint32      // see EncodeOptions.Fingerprint
+	err         error
+}
+
+// codecCache maps a reflect.Type to its *typeCodec
+var codecCache sync.Map
+
+// codecFor returns the compiled codec of a type used as a top level value or as a struct field without tags
+func codecFor(rt reflect.Type) *typeCodec {
+	if tc, ok := codecCache.Load(rt); ok {
+		return tc.(*typeCodec)
+	}
+
+	tc := &typeCodec{}
+	if rt.Kind() == reflect.Struct && !isOptional(rt) && !implementsMarshaler(rt) && !isBlobStruct(rt) {
+		tc.plan, tc.err = compileStruct(rt)
+		if tc.err == nil {
+			tc.codec = codec{encode: structEncoder(tc.plan), decode: structDecoder(tc.plan), skip: structSkipper(tc.plan), info: structInfo(tc.plan)}
+			tc.err = checkBudget(tc.plan, tc.codec.info)
+		}
+	} else {
+		tc.codec, tc.err = compileCodec(rt, nil)
+	}
+	if tc.err == nil {
+		tc.fingerprint = fingerprint(tc.codec.info, rt)
+	}
+
+	actual, _ := codecCache.LoadOrStore(rt, tc)
+	return actual.(*typeCodec)
+}
+
+// clearCodecCache drops every compiled codec so that they are compiled again with the current schemas
+func clearCodecCache() {
+	codecCache.Range(func(key, _ any) bool {
+		codecCache.Delete(key)
+		return true
+	})
+}
+
+// compileStruct builds the plan of a struct type by parsing the tags of every field
+func compileStruct(rt reflect.Type) (*structPlan, error) {
+	plan := &structPlan{typ: rt, validator: reflect.PointerTo(rt).Implements(validatorType), unknown: noUnknownField}
+	for i := 0; i < rt.NumField(); i++ {
+		structField := rt.Field(i)
+		structTag := fieldTag(rt, structField)
+
+		if structField.Name == "_" {
+			if structTag == "" {
+				continue
+			}
+			opts, err := getStructOptions(structTag)
+			if err != nil {
+				return nil, fmt.Errorf("type=%v: %w", rt, err)
+			}
+			plan.opts = opts
+			continue
+		}
+
+		if structField.Type == unknownType {
+			if err := compileUnknown(plan, structField, structTag, i); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		cbStructTags, order, err := getCoachbufTag(structField.Name, structTag)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cbStructTags) < 1 {
+			continue
+		}
+		if !structField.IsExported() {
+			return nil, fmt.Errorf("field=%s must be exported to be decoded: %w", structField.Name, ErrUnsupportedType)
+		}
+		if plan.orderToField[order] != 0 {
+			return nil, fmt.Errorf("field=%s: %w", structField.Name, ErrDuplicateOrdering)
+		}
+
+		fieldCodec, err := compileCodec(structField.Type, cbStructTags)
+		if err != nil {
+			return nil, fmt.Errorf("field=%s: %w", structField.Name, err)
+		}
+		check, err := compileConstraints(structField.Type, cbStructTags)
+		if err != nil {
+			return nil, fmt.Errorf("field=%s: %w", structField.Name, err)
+		}
+
+		plan.fields = append(plan.fields, fieldPlan{
+			name:  structField.Name,
+			index: i,
+			order: order,
+			tags:  cbStructTags,
+			codec: fieldCodec,
+			check: check,
+		})
+		plan.orderToField[order] = len(plan.fields)
+	}
+
+	plan.byOrder = make([]int, 0, len(plan.fields))
+	for _, fieldNumber := range plan.orderToField {
+		if fieldNumber != 0 {
+			plan.byOrder = append(plan.byOrder, fieldNumber-1)
+		}
+	}
+
+	return plan, nil
+}
+
+// compileCodec selects the encoder and decoder of a type given the tags of the field holding it
+func compileCodec(rt reflect.Type, tags []string) (codec, error) {
+	if isOptional(rt) {
+		inner, err := compileCodec(rt.Field(optionalValueField).Type, tags)
+		if err != nil {
+			return codec{}, err
+		}
+		info := inner.info
+		info.optional = true
+		info.minBits = 1
+		info.maxBits = addBits(1, inner.info.maxBits)
+		return codec{encode: optionalEncoder(inner.encode), decode: optionalDecoder(inner.decode), skip: optionalSkipper(inner.skip), info: info}, nil
+	}
+
+	if c, ok, err := compileMarshaler(rt, tags); ok {
+		return c, err
+	}
+
+	switch rt {
+	case addrType:
+		info := codecInfo{encoding: EncodingAddr, minBits: coachwire.AddrMinBits, maxBits: coachwire.AddrMaxBits}
+		return codec{encode: addrEncoder, decode: addrDecoder, skip: addrSkipper, info: info}, nil
+	case addrPortType:
+		info := codecInfo{encoding: EncodingAddrPort, minBits: coachwire.AddrPortMinBits, maxBits: coachwire.AddrPortMaxBits}
+		return codec{encode: addrPortEncoder, decode: addrPortDecoder, skip: addrPortSkipper, info: info}, nil
+	case bigIntType:
+		maxBits, err := getMaxBitsTag(tags)
+		if err != nil {
+			return codec{}, err
+		}
+		info := codecInfo{encoding: EncodingBigInt}
+		info.minBits, info.maxBits = coachwire.BigIntBits(maxBits)
+		return codec{encode: bigIntEncoder(maxBits), decode: bigIntDecoder(maxBits), skip: bigIntSkipper(maxBits), info: info}, nil
+	}
+
+	switch rt.Kind() {
+	case reflect.Struct:
+		if isBlobStruct(rt) {
+			c, _, err := compileBlob(rt, tags)
+			return c, err
+		}
+		tc := codecFor(rt)
+		return tc.codec, tc.err
+	case reflect.Int32:
+		return compileInt32(tags)
+	case reflect.Float32:
+		info := codecInfo{encoding: EncodingFloat32, minBits: coachwire.FloatBits, maxBits: coachwire.FloatBits}
+		return codec{encode: float32Encoder, decode: float32Decoder, skip: bitsSkipper(coachwire.FloatBits), info: info}, nil
+	case reflect.Complex64, reflect.Complex128:
+		return compileComplex(rt.Kind(), tags)
+	default:
+		if c, ok, err := compileBlob(rt, tags); ok {
+			return c, err
+		}
+		return codec{}, fmt.Errorf("type=%v: %w", rt, ErrUnsupportedType)
+	}
+}
+
+// isBlobStruct reports whether a struct type without tagged fields is encoded as a blob by compileBlob
+func isBlobStruct(rt reflect.Type) bool {
+	return !hasTaggedFields(rt) && implementsAny(rt, binaryMarshalerType, binaryUnmarshalerType, textMarshalerType, textUnmarshalerType)
+}
+
+// compileInt32 selects the int32 encoding given by the enc tag or the bounded range given by min and max tags
+func compileInt32(tags []string) (codec, error) {
+	enc, err := getEncodingTag(tags)
+	if err != nil {
+		return codec{}, err
+	}
+
+	switch enc {
+	case cbEncodingVarint:
+		info := codecInfo{encoding: EncodingVarint, minBits: coachwire.VarIntegerMinBits, maxBits: coachwire.VarIntegerMaxBits}
+		return codec{encode: varIntegerEncoder, decode: varIntegerDecoder, skip: varIntegerSkipper, info: info}, nil
+	case cbEncodingGamma:
+		info := codecInfo{encoding: EncodingGamma, minBits: coachwire.GammaIntegerMinBits, maxBits: coachwire.GammaIntegerMaxBits}
+		return codec{encode: gammaIntegerEncoder, decode: gammaIntegerDecoder, skip: gammaIntegerSkipper, info: info}, nil
+	}
+
+	min, max, err := getMinAndMaxTags(tags)
+	if err != nil {
+		return codec{}, err
+	}
+	if min >= max {
+		return codec{}, fmt.Errorf("min=%d must be less than max=%d: %w", min, max, ErrInvalidTagFormat)
+	}
+
+	bits := coachwire.IntegerBits(min, max)
+	info := codecInfo{encoding: EncodingInt, min: float64(min), max: float64(max), minBits: bits, maxBits: bits}
+	return codec{encode: int32Encoder(min, max), decode: int32Decoder(min, max), skip: bitsSkipper(bits), info: info}, nil
+}
+
+// compileComplex selects between full precision and compressed parts given the float range tags
+func compileComplex(kind reflect.Kind, tags []string) (codec, error) {
+	min, max, res, compressed, err := getFloatRangeTags(tags)
+	if err != nil {
+		return codec{}, err
+	}
+	if compressed && min >= max {
+		return codec{}, fmt.Errorf("min=%v must be less than max=%v: %w", min, max, ErrInvalidTagFormat)
+	}
+	if compressed {
+		// the steps are checked before CompressedFloatBits converts them to 32 bits
+		if steps := coachwire.CompressedFloatSteps(min, max, res); !(steps <= math.MaxUint32) {
+			return codec{}, fmt.Errorf("min=%v, max=%v and res=%v require %v steps, at most %d: %w", min, max, res, steps, uint32(math.MaxUint32), ErrInvalidTagFormat)
+		}
+		if bits := coachwire.CompressedFloatBits(min, max, res); bits <= 0 {
+			return codec{}, fmt.Errorf("min=%v, max=%v and res=%v require %d bits, must be in (0,32]: %w", min, max, res, bits, ErrInvalidTagFormat)
+		}
+	}
+
+	part := complexPart{kind: kind, compressed: compressed, min: min, max: max, res: res}
+
+	var info codecInfo
+	switch {
+	case compressed:
+		bits := 2 * coachwire.CompressedFloatBits(min, max, res)
+		info = codecInfo{encoding: EncodingCompressed, min: float64(min), max: float64(max), res: float64(res), minBits: bits, maxBits: bits}
+	case kind == reflect.Complex64:
+		info = codecInfo{encoding: EncodingFloat32, minBits: 2 * coachwire.FloatBits, maxBits: 2 * coachwire.FloatBits}
+	default:
+		info = codecInfo{encoding: EncodingFloat64, minBits: 2 * coachwire.Float64Bits, maxBits: 2 * coachwire.Float64Bits}
+	}
+
+	return codec{encode: complexEncoder(part), decode: complexDecoder(part), skip: bitsSkipper(info.maxBits), info: info}, nil
+}
+
+// complexPart describes how each part of a complex number is written
+type complexPart struct {
+	kind          reflect.Kind
+	compressed    bool
+	min, max, res float32
+}
+
+// checkBudget returns an error when the worst case size of a struct exceeds the maxbits option of the struct
+func checkBudget(plan *structPlan, info codecInfo) error {
+	if plan.opts.maxBits == 0 {
+		return nil
+	}
+	if info.maxBits == unboundedBits {
+		return fmt.Errorf("type=%v worst case size is unbounded, maxbits=%d: %w", plan.typ, plan.opts.maxBits, ErrSizeBudgetExceeded)
+	}
+	if info.maxBits > plan.opts.maxBits {
+		return fmt.Errorf("type=%v worst case size=%d bits, maxbits=%d: %w", plan.typ, info.maxBits, plan.opts.maxBits, ErrSizeBudgetExceeded)
+	}
+
+	return nil
+}
+
+// unboundedBits is the maxBits of a codecInfo whose values have no known upper bound
+const unboundedBits = -1
+
+// structInfo describes a struct as the sum of the ordering number and the value of every tagged field,
+// ordering numbers are not counted for a positional struct and a versioned struct adds its field count and value headers.
+// The raw bits of an Unknown field have no upper bound
+func structInfo(plan *structPlan) codecInfo {
+	headerBits := orderingBits
+	if plan.opts.positional {
+		headerBits = 0
+	}
+
+	info := codecInfo{encoding: EncodingStruct, plan: plan}
+	if plan.opts.versioned {
+		info.minBits, info.maxBits = fieldCountBits, fieldCountBits
+	}
+	for i := range plan.fields {
+		fieldInfo := plan.fields[i].codec.info
+		minHeader, maxHeader := headerBits, headerBits
+		if plan.opts.versioned {
+			minValueHeader, maxValueHeader := valueHeaderBits(fieldInfo)
+			minHeader, maxHeader = headerBits+minValueHeader, addBits(headerBits, maxValueHeader)
+		}
+		info.minBits += minHeader + fieldInfo.minBits
+		info.maxBits = addBits(info.maxBits, addBits(maxHeader, fieldInfo.maxBits))
+	}
+	if plan.unknown != noUnknownField {
+		info.maxBits = unboundedBits
+	}
+
+	return info
+}
+
+// orderingBits is the number of bits of the ordering number preceding every field
+var orderingBits = coachwire.IntegerBits(cbMinOrderingNumber, cbMaxOrderingNumber)
+
+// addBits adds two maxBits values, the result is unboundedBits when either value is
+func addBits(a, b int) int {
+	if a == unboundedBits || b == unboundedBits {
+		return unboundedBits
+	}
+
+	return a + b
+}
@@ -0,0 +1,97 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type benchStruct struct {
+	ID     int32   `coachbuf:"1,min=0,max=100000"`
+	Health int32   `coachbuf:"2,min=0,max=100"`
+	Score  int32   `coachbuf:"3,enc=varint"`
+	X      float32 `coachbuf:"4"`
+	Y      float32 `coachbuf:"5"`
+	Inner  struct {
+		A int32 `coachbuf:"1,min=-10,max=10"`
+		B int32 `coachbuf:"2"`
+	} `coachbuf:"6"`
+}
+
+func TestCompiledCodec(t *testing.T) {
+	t.Run("concurrent first use", func(t *testing.T) {
+		t.Parallel()
+
+		type TestStruct struct {
+			Int32   int32   `coachbuf:"1,min=-50,max=50"`
+			Float32 float32 `coachbuf:"2"`
+		}
+
+		var wg sync.WaitGroup
+		for i := int32(0); i < 16; i++ {
+			wg.Add(1)
+			go func(i int32) {
+				defer wg.Done()
+
+				input := TestStruct{Int32: i, Float32: float32(i) / 2}
+				data, err := coachbuf.Encode(input)
+				if err != nil {
+					t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+					return
+				}
+
+				var result TestStruct
+				if err := coachbuf.Decode(data, &result); err != nil {
+					t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+				}
+				if result != input {
+					t.Errorf("Decode() = %v, want %v", result, input)
+				}
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("tag error is reported on every call", func(t *testing.T) {
+		t.Parallel()
+
+		input := struct {
+			Int32 int32 `coachbuf:"1,min=10,max=10"`
+		}{Int32: 10}
+		want := coachbuf.ErrInvalidTagFormat
+
+		for i := 0; i < 2; i++ {
+			if _, err := coachbuf.Encode(input); !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err, want)
+			}
+		}
+	})
+}
+
+func BenchmarkEncode(b *testing.B) {
+	input := benchStruct{ID: 500, Health: 80, Score: 1234, X: 1.5, Y: -3}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := coachbuf.Encode(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data, err := coachbuf.Encode(benchStruct{ID: 500, Health: 80, Score: 1234, X: 1.5, Y: -3})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var result benchStruct
+		if err := coachbuf.Decode(data, &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
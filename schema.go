@@ -0,0 +1,227 @@
+package coachbuf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FieldSchema describes the coachbuf metadata of a single struct field, see Field
+type FieldSchema struct {
+	name string
+	tag  string
+}
+
+// FieldOption is a single value of the metadata of a field, equivalent to a value of the coachbuf struct tag
+type FieldOption struct {
+	value string
+}
+
+// Field describes the field name with the given ordering number and options, equivalent to the struct tag
+// `coachbuf:"<order>,<options>..."`
+func Field(name string, order int, opts ...FieldOption) FieldSchema {
+	values := make([]string, 0, len(opts)+1)
+	values = append(values, strconv.Itoa(order))
+	for _, opt := range opts {
+		values = append(values, opt.value)
+	}
+
+	return FieldSchema{name: name, tag: strings.Join(values, ",")}
+}
+
+// Min is equivalent to the min tag
+func Min(v float64) FieldOption {
+	return FieldOption{value: "min=" + strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+// Max is equivalent to the max tag
+func Max(v float64) FieldOption {
+	return FieldOption{value: "max=" + strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+// Res is equivalent to the res tag
+func Res(v float64) FieldOption {
+	return FieldOption{value: "res=" + strconv.FormatFloat(v, 'f', -1, 64)}
+}
+
+// Enc is equivalent to the enc tag, either "varint" or "gamma"
+func Enc(enc string) FieldOption {
+	return FieldOption{value: "enc=" + enc}
+}
+
+// MaxBitLen is equivalent to the maxbits tag of a *big.Int field
+func MaxBitLen(n int) FieldOption {
+	return FieldOption{value: "maxbits=" + strconv.Itoa(n)}
+}
+
+// MaxLen is equivalent to the maxlen tag
+func MaxLen(n int) FieldOption {
+	return FieldOption{value: "maxlen=" + strconv.Itoa(n)}
+}
+
+// Option is a key=value option passed through to the Tag of a Marshaler, Unmarshaler or Serializer
+func Option(key, value string) FieldOption {
+	return FieldOption{value: key + "=" + value}
+}
+
+// schemas maps a registered reflect.Type to the coachbuf tag of each of its described fields by field name
+var (
+	schemasMu sync.RWMutex
+	schemas   = map[reflect.Type]map[string]string{}
+)
+
+// RegisterSchema describes the coachbuf metadata of a struct type whose source can not be tagged
+//
+// A registered schema takes precedence over the struct tags of the type, fields not described are not encoded.
+// Registering a type again replaces its schema. Schemas should be registered before the type is first encoded,
+// for example in an init function, since a Codec created earlier keeps using the previous metadata
+func RegisterSchema(rt reflect.Type, fields ...FieldSchema) error {
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return fmt.Errorf("type=%v must be a struct: %w", rt, ErrUnsupportedType)
+	}
+
+	tags := make(map[string]string, len(fields))
+	for _, field := range fields {
+		if structField, ok := rt.FieldByName(field.name); !ok || len(structField.Index) != 1 {
+			return fmt.Errorf("field=%s not found in type=%v: %w", field.name, rt, ErrInvalidTagFormat)
+		}
+		if _, ok := tags[field.name]; ok {
+			return fmt.Errorf("field=%s described more than once: %w", field.name, ErrInvalidTagFormat)
+		}
+		tags[field.name] = field.tag
+	}
+
+	schemasMu.Lock()
+	previous, registered := schemas[rt]
+	schemas[rt] = tags
+	schemasMu.Unlock()
+
+	// compiled codecs of every type may embed the previous metadata of rt
+	clearCodecCache()
+
+	if err := codecFor(rt).err; err != nil {
+		schemasMu.Lock()
+		if registered {
+			schemas[rt] = previous
+		} else {
+			delete(schemas, rt)
+		}
+		schemasMu.Unlock()
+		clearCodecCache()
+
+		return err
+	}
+
+	return nil
+}
+
+// fieldTag returns the coachbuf tag of a struct field, from the registered schema of the struct type when there is one
+func fieldTag(rt reflect.Type, structField reflect.StructField) string {
+	schemasMu.RLock()
+	tags, ok := schemas[rt]
+	schemasMu.RUnlock()
+	if ok {
+		return tags[structField.Name]
+	}
+
+	return structField.Tag.Get(cbStructTagsKey)
+}
+
+// Encoding names the wire representation of a value
+type Encoding string
+
+const (
+	EncodingInt        Encoding = "int"        // int32 in the range [Min, Max]
+	EncodingVarint     Encoding = "varint"     // int32 with the enc=varint tag
+	EncodingGamma      Encoding = "gamma"      // int32 with the enc=gamma tag
+	EncodingFloat32    Encoding = "float32"    // float32 or both parts of a complex64 with full precision
+	EncodingFloat64    Encoding = "float64"    // both parts of a complex128 with full precision
+	EncodingCompressed Encoding = "compressed" // both parts of a complex number in the range [Min, Max] with a precision of Res
+	EncodingAddr       Encoding = "addr"       // netip.Addr
+	EncodingAddrPort   Encoding = "addrport"   // netip.AddrPort
+	EncodingBigInt     Encoding = "bigint"     // *big.Int
+	EncodingBlob       Encoding = "blob"       // encoding.BinaryMarshaler or encoding.TextMarshaler of at most Max bytes
+	EncodingStruct     Encoding = "struct"     // nested struct described by Fields
+	EncodingCustom     Encoding = "custom"     // Marshaler, Unmarshaler or Serializer
+)
+
+// Schema describes the wire representation of a type as compiled by Encode and Decode
+type Schema struct {
+	Type   reflect.Type
+	Fields []FieldInfo // tagged fields in declaration order, empty when Type is not a struct
+
+	// MinBits and MaxBits bound the number of bits of a value before padding to 32 bits,
+	// MaxBits is -1 when a value has no known upper bound
+	MinBits, MaxBits int
+
+	// Fingerprint is the hash of the schema written before the payload by EncodeOptions.Fingerprint
+	Fingerprint uint32
+}
+
+// FieldInfo describes the wire representation of a single tagged field
+type FieldInfo struct {
+	Name     string
+	Order    int
+	Type     reflect.Type
+	Encoding Encoding
+	Optional bool // the value is preceded by a presence bit, Type is the Optional type
+
+	// Min, Max and Res are set for the encodings documenting them
+	Min, Max, Res float64
+
+	// MinBits and MaxBits bound the number of bits of the value excluding its ordering number,
+	// MaxBits is -1 when a value has no known upper bound
+	MinBits, MaxBits int
+
+	Fields []FieldInfo // fields of a nested struct
+}
+
+// SchemaOf returns the description of the type of v, or the first error found in its tags
+func SchemaOf(v any) (*Schema, error) {
+	rt := reflect.TypeOf(v)
+	if rt == nil {
+		return nil, fmt.Errorf("schema of nil value: %w", ErrUnsupportedType)
+	}
+
+	tc := codecFor(rt)
+	if tc.err != nil {
+		return nil, tc.err
+	}
+
+	return &Schema{
+		Type:        rt,
+		Fields:      fieldInfos(tc.codec.info.plan),
+		MinBits:     tc.codec.info.minBits,
+		MaxBits:     tc.codec.info.maxBits,
+		Fingerprint: tc.fingerprint,
+	}, nil
+}
+
+// fieldInfos describes every field of a struct plan, nil plans have no fields
+func fieldInfos(plan *structPlan) []FieldInfo {
+	if plan == nil {
+		return nil
+	}
+
+	fields := make([]FieldInfo, len(plan.fields))
+	for i, field := range plan.fields {
+		info := field.codec.info
+		fields[i] = FieldInfo{
+			Name:     field.name,
+			Order:    int(field.order),
+			Type:     plan.typ.Field(field.index).Type,
+			Encoding: info.encoding,
+			Optional: info.optional,
+			Min:      info.min,
+			Max:      info.max,
+			Res:      info.res,
+			MinBits:  info.minBits,
+			MaxBits:  info.maxBits,
+			Fields:   fieldInfos(info.plan),
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,198 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+// vendored types stand for types from packages whose source can not be tagged
+type (
+	vendoredPlayer struct {
+		Health int32
+		Score  int32
+		Speed  complex64
+		Name   string
+	}
+	vendoredTagged struct {
+		Health int32 `coachbuf:"1"`
+		Armor  int32 `coachbuf:"2,min=0,max=10"`
+	}
+	vendoredInvalid struct {
+		Health int32
+	}
+	vendoredEmbedded struct {
+		vendoredInvalid
+	}
+)
+
+func TestRegisterSchema(t *testing.T) {
+	t.Run("Encode and Decode", func(t *testing.T) {
+		t.Parallel()
+
+		err := coachbuf.RegisterSchema(reflect.TypeOf(vendoredPlayer{}),
+			coachbuf.Field("Health", 1, coachbuf.Min(0), coachbuf.Max(100)),
+			coachbuf.Field("Score", 2, coachbuf.Enc("gamma")),
+			coachbuf.Field("Speed", 3, coachbuf.Min(-10), coachbuf.Max(10), coachbuf.Res(0.5)),
+		)
+		if err != nil {
+			t.Fatalf("RegisterSchema() = %v, want %v", err.Error(), nil)
+		}
+
+		// output must be identical to the equivalent tagged struct
+		type Tagged struct {
+			Health int32     `coachbuf:"1,min=0,max=100"`
+			Score  int32     `coachbuf:"2,enc=gamma"`
+			Speed  complex64 `coachbuf:"3,min=-10,max=10,res=0.5"`
+		}
+
+		input := vendoredPlayer{Health: 80, Score: 1200, Speed: complex(2.5, -4), Name: "not encoded"}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		want, err := coachbuf.Encode(Tagged{Health: input.Health, Score: input.Score, Speed: input.Speed})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if string(data) != string(want) {
+			t.Errorf("Encode() = %v, want %v", data, want)
+		}
+
+		var result vendoredPlayer
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		input.Name = ""
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+	})
+
+	t.Run("takes precedence over tags", func(t *testing.T) {
+		t.Parallel()
+
+		if err := coachbuf.RegisterSchema(reflect.TypeOf(vendoredTagged{}), coachbuf.Field("Health", 5, coachbuf.Min(0), coachbuf.Max(100))); err != nil {
+			t.Fatalf("RegisterSchema() = %v, want %v", err.Error(), nil)
+		}
+
+		data, err := coachbuf.Encode(vendoredTagged{Health: 100, Armor: 5})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result vendoredTagged
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+		}
+		if want := (vendoredTagged{Health: 100}); result != want {
+			t.Errorf("Decode() = %v, want %v", result, want)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name   string
+			typ    reflect.Type
+			fields []coachbuf.FieldSchema
+			err    error
+		}{
+			{name: "not a struct", typ: reflect.TypeOf(int32(0)), err: coachbuf.ErrUnsupportedType},
+			{name: "unknown field", typ: reflect.TypeOf(vendoredInvalid{}), fields: []coachbuf.FieldSchema{coachbuf.Field("Armor", 1)}, err: coachbuf.ErrInvalidTagFormat},
+			{name: "promoted field", typ: reflect.TypeOf(vendoredEmbedded{}), fields: []coachbuf.FieldSchema{coachbuf.Field("Health", 1)}, err: coachbuf.ErrInvalidTagFormat},
+			{name: "field described twice", typ: reflect.TypeOf(vendoredInvalid{}), fields: []coachbuf.FieldSchema{coachbuf.Field("Health", 1), coachbuf.Field("Health", 2)}, err: coachbuf.ErrInvalidTagFormat},
+			{name: "out of range ordering", typ: reflect.TypeOf(vendoredInvalid{}), fields: []coachbuf.FieldSchema{coachbuf.Field("Health", 256)}, err: coachbuf.ErrOutOfRangeOrdering},
+			{name: "invalid range", typ: reflect.TypeOf(vendoredInvalid{}), fields: []coachbuf.FieldSchema{coachbuf.Field("Health", 1, coachbuf.Min(10), coachbuf.Max(0))}, err: coachbuf.ErrInvalidTagFormat},
+		}
+
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				if err := coachbuf.RegisterSchema(tt.typ, tt.fields...); !errors.Is(err, tt.err) {
+					t.Errorf("RegisterSchema() = %v, want %v", err, tt.err)
+				}
+			})
+		}
+
+		// a rejected schema is not registered
+		if _, err := coachbuf.Encode(vendoredInvalid{Health: 1}); err != nil {
+			t.Errorf("Encode() = %v, want %v", err, nil)
+		}
+	})
+}
+
+func TestSchemaOf(t *testing.T) {
+	t.Run("fields", func(t *testing.T) {
+		t.Parallel()
+
+		type Vector struct {
+			X int32 `coachbuf:"1,min=-10,max=10"`
+		}
+		type Player struct {
+			Health   int32                    `coachbuf:"1,min=0,max=100"`
+			Score    int32                    `coachbuf:"2,enc=varint"`
+			Speed    complex64                `coachbuf:"3,min=0,max=10,res=0.5"`
+			Ratio    float32                  `coachbuf:"4"`
+			Position Vector                   `coachbuf:"5"`
+			Shield   coachbuf.Optional[int32] `coachbuf:"6,min=0,max=3"`
+			Name     string
+		}
+
+		schema, err := coachbuf.SchemaOf(Player{})
+		if err != nil {
+			t.Fatalf("SchemaOf() = %v, want %v", err.Error(), nil)
+		}
+
+		want := []coachbuf.FieldInfo{
+			{Name: "Health", Order: 1, Type: reflect.TypeOf(int32(0)), Encoding: coachbuf.EncodingInt, Min: 0, Max: 100, MinBits: 7, MaxBits: 7},
+			{Name: "Score", Order: 2, Type: reflect.TypeOf(int32(0)), Encoding: coachbuf.EncodingVarint, MinBits: 5, MaxBits: 40},
+			{Name: "Speed", Order: 3, Type: reflect.TypeOf(complex64(0)), Encoding: coachbuf.EncodingCompressed, Min: 0, Max: 10, Res: 0.5, MinBits: 10, MaxBits: 10},
+			{Name: "Ratio", Order: 4, Type: reflect.TypeOf(float32(0)), Encoding: coachbuf.EncodingFloat32, MinBits: 32, MaxBits: 32},
+			{Name: "Position", Order: 5, Type: reflect.TypeOf(Vector{}), Encoding: coachbuf.EncodingStruct, MinBits: 13, MaxBits: 13, Fields: []coachbuf.FieldInfo{
+				{Name: "X", Order: 1, Type: reflect.TypeOf(int32(0)), Encoding: coachbuf.EncodingInt, Min: -10, Max: 10, MinBits: 5, MaxBits: 5},
+			}},
+			{Name: "Shield", Order: 6, Type: reflect.TypeOf(coachbuf.Optional[int32]{}), Encoding: coachbuf.EncodingInt, Optional: true, Min: 0, Max: 3, MinBits: 1, MaxBits: 3},
+		}
+		if !reflect.DeepEqual(schema.Fields, want) {
+			t.Errorf("SchemaOf() = %+v, want %+v", schema.Fields, want)
+		}
+		if schema.MinBits != 6*8+7+5+10+32+13+1 || schema.MaxBits != 6*8+7+40+10+32+13+3 {
+			t.Errorf("SchemaOf() bits = [%v, %v], want [%v, %v]", schema.MinBits, schema.MaxBits, 6*8+7+5+10+32+13+1, 6*8+7+40+10+32+13+3)
+		}
+	})
+
+	t.Run("unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		type Custom struct {
+			Version Version `coachbuf:"1"`
+		}
+
+		schema, err := coachbuf.SchemaOf(Custom{})
+		if err != nil {
+			t.Fatalf("SchemaOf() = %v, want %v", err.Error(), nil)
+		}
+		if schema.MaxBits != -1 || schema.Fields[0].Encoding != coachbuf.EncodingCustom {
+			t.Errorf("SchemaOf() = %+v, want unbounded custom field", schema)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+
+		type InvalidRange struct {
+			Int32 int32 `coachbuf:"1,min=100,max=0"`
+		}
+
+		if _, err := coachbuf.SchemaOf(InvalidRange{}); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("SchemaOf() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+		if _, err := coachbuf.SchemaOf(nil); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+			t.Errorf("SchemaOf() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+		}
+	})
+}
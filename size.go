@@ -0,0 +1,39 @@
+package coachbuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// MaxBits returns the worst case size in bits of a value of type T derived from its tags, before padding to 32 bits
+// -1 is returned when the size has no upper bound, such as for a Marshaler
+func MaxBits[T any]() (int, error) {
+	tc := codecFor(reflect.TypeOf((*T)(nil)).Elem())
+	if tc.err != nil {
+		return 0, tc.err
+	}
+
+	return tc.codec.info.maxBits, nil
+}
+
+// SizeBits returns the number of bits Encode writes for v before padding to 32 bits, without storing any of them
+func SizeBits(v any) (int, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return 0, fmt.Errorf("size of nil value: %w", ErrUnsupportedType)
+	}
+
+	tc := codecFor(rv.Type())
+	if tc.err != nil {
+		return 0, tc.err
+	}
+
+	writer := bitpacker.NewMeasureWriter()
+	if err := tc.codec.encode(&encodeState{writer: writer}, rv); err != nil {
+		return 0, topLevelError(err, rv.Type())
+	}
+
+	return writer.NumBitsWritten(), nil
+}
@@ -0,0 +1,109 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type sizedPlayer struct {
+	Health int32                    `coachbuf:"1,min=0,max=100"`
+	Score  int32                    `coachbuf:"2,enc=gamma"`
+	Shield coachbuf.Optional[int32] `coachbuf:"3,min=0,max=3"`
+}
+
+func TestMaxBits(t *testing.T) {
+	t.Run("derived from tags", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := coachbuf.MaxBits[sizedPlayer]()
+		if err != nil {
+			t.Fatalf("MaxBits() = %v, want %v", err.Error(), nil)
+		}
+		if want := 3*8 + 7 + 65 + 1 + 2; result != want {
+			t.Errorf("MaxBits() = %v, want %v", result, want)
+		}
+	})
+
+	t.Run("unbounded", func(t *testing.T) {
+		t.Parallel()
+
+		result, err := coachbuf.MaxBits[Version]()
+		if err != nil {
+			t.Fatalf("MaxBits() = %v, want %v", err.Error(), nil)
+		}
+		if result != -1 {
+			t.Errorf("MaxBits() = %v, want %v", result, -1)
+		}
+	})
+}
+
+func TestSizeBits(t *testing.T) {
+	tests := []struct {
+		name  string
+		input any
+	}{
+		{name: "without optional", input: sizedPlayer{Health: 10, Score: 3}},
+		{name: "with optional", input: sizedPlayer{Health: 10, Score: -4000, Shield: coachbuf.Some[int32](2)}},
+		{name: "Marshaler", input: Version{Major: 100, Minor: 3}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := coachbuf.SizeBits(tt.input)
+			if err != nil {
+				t.Fatalf("SizeBits() = %v, want %v", err.Error(), nil)
+			}
+
+			// the measured size must match the bits written by Encode
+			data, err := coachbuf.Encode(tt.input)
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+			if want := (result + 31) / 32 * 4; len(data) != want {
+				t.Errorf("SizeBits() = %v, want %v bytes once padded, got %v", result, want, len(data))
+			}
+		})
+	}
+}
+
+func TestStructMaxBitsOption(t *testing.T) {
+	type WithinBudget struct {
+		_      struct{} `coachbuf:"maxbits=15"`
+		Health int32    `coachbuf:"1,min=0,max=100"`
+	}
+	type OverBudget struct {
+		_      struct{} `coachbuf:"maxbits=14"`
+		Health int32    `coachbuf:"1,min=0,max=100"`
+	}
+	type Unbounded struct {
+		_       struct{} `coachbuf:"maxbits=1200"`
+		Version Version  `coachbuf:"1"`
+	}
+	type NestedOverBudget struct {
+		Nested OverBudget `coachbuf:"1"`
+	}
+	type UnknownOption struct {
+		_ struct{} `coachbuf:"budget=10"`
+	}
+
+	if _, err := coachbuf.NewCodec[WithinBudget](); err != nil {
+		t.Errorf("NewCodec() = %v, want %v", err, nil)
+	}
+	if _, err := coachbuf.NewCodec[OverBudget](); !errors.Is(err, coachbuf.ErrSizeBudgetExceeded) {
+		t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrSizeBudgetExceeded)
+	}
+	if _, err := coachbuf.NewCodec[Unbounded](); !errors.Is(err, coachbuf.ErrSizeBudgetExceeded) {
+		t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrSizeBudgetExceeded)
+	}
+	if _, err := coachbuf.Encode(NestedOverBudget{}); !errors.Is(err, coachbuf.ErrSizeBudgetExceeded) {
+		t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrSizeBudgetExceeded)
+	}
+	if _, err := coachbuf.NewCodec[UnknownOption](); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+		t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+	}
+}
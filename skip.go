@@ -0,0 +1,185 @@
+package coachbuf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// ErrInvalidFieldMask indicates that a FieldMask selects a field that does not exist in the type being decoded
+var ErrInvalidFieldMask = errors.New("invalid field mask")
+
+// FieldMask selects the fields decoded by DecodeFields, see Fields
+type FieldMask struct {
+	fields []any
+}
+
+// Fields selects fields by the ordering number of a top level field, given as an int,
+// or by a dot separated path of field names, given as a string such as "Header.Kind".
+// Selecting a nested struct decodes every field of it
+func Fields(fields ...any) FieldMask {
+	return FieldMask{fields: append([]any{}, fields...)}
+}
+
+// DecodeFields deserializes only the fields selected by mask into the value v
+//
+// The bits of every other field are skipped using the widths given by the tags of the type, those fields are not
+// assigned, allocated or validated and partially decoded structs are not passed to Validate.
+// Values of a Marshaler or Serializer are decoded into a temporary value to be skipped.
+// argument v must be a non-nil pointer to a struct
+func DecodeFields(data []byte, v any, mask FieldMask) error {
+	return DecodeOptions{Fields: mask}.Decode(data, v)
+}
+
+// fieldSelection holds the selected fields of a struct by ordering number,
+// a field mapped to a nil selection is selected as a whole
+type fieldSelection struct {
+	fields map[int32]*fieldSelection
+}
+
+// resolve returns the selection of the fields of m within the struct plan
+func (m FieldMask) resolve(plan *structPlan) (*fieldSelection, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("fields can only be selected in a struct: %w", ErrInvalidFieldMask)
+	}
+
+	selection := &fieldSelection{fields: map[int32]*fieldSelection{}}
+	for _, field := range m.fields {
+		switch f := field.(type) {
+		case int:
+			if f < cbMinOrderingNumber || f > cbMaxOrderingNumber || plan.orderToField[f] == 0 {
+				return nil, fmt.Errorf("order=%d is not used by type=%v: %w", f, plan.typ, ErrInvalidFieldMask)
+			}
+			selection.fields[int32(f)] = nil
+		case string:
+			if err := selection.add(plan, strings.Split(f, ".")); err != nil {
+				return nil, fmt.Errorf("path=%s: %w", f, err)
+			}
+		default:
+			return nil, fmt.Errorf("field=%v of type=%T must be an int or a string: %w", field, field, ErrInvalidFieldMask)
+		}
+	}
+
+	return selection, nil
+}
+
+// add selects the field of plan found by following the field names of path
+func (s *fieldSelection) add(plan *structPlan, path []string) error {
+	var field *fieldPlan
+	for i := range plan.fields {
+		if plan.fields[i].name == path[0] {
+			field = &plan.fields[i]
+		}
+	}
+	if field == nil {
+		return fmt.Errorf("field=%s is not a tagged field of type=%v: %w", path[0], plan.typ, ErrInvalidFieldMask)
+	}
+
+	child, ok := s.fields[field.order]
+	switch {
+	case len(path) == 1:
+		s.fields[field.order] = nil
+		return nil
+	case ok && child == nil:
+		// the field is already selected as a whole
+		return nil
+	case field.codec.info.plan == nil:
+		return fmt.Errorf("field=%s is not a struct: %w", field.name, ErrInvalidFieldMask)
+	case !ok:
+		child = &fieldSelection{fields: map[int32]*fieldSelection{}}
+		s.fields[field.order] = child
+	}
+
+	return child.add(field.codec.info.plan, path[1:])
+}
+
+// structSkipper reads past as many ordering numbers as there are tagged fields and the value following each of them
+func structSkipper(plan *structPlan) skipperFunc {
+	return func(d *decodeState) error {
+		d.depth++
+		defer func() { d.depth-- }()
+		if d.depth > cbMaxDecodeDepth {
+			return fmt.Errorf("depth=%d: %w", d.depth, ErrMaxDepthExceeded)
+		}
+
+		layout := plan.layout(d.positional, d.versioned)
+		if layout == layoutVersioned {
+			return skipVersioned(d)
+		}
+
+		for readCounter := 0; readCounter < len(plan.fields); readCounter++ {
+			field, start, err := readField(d, plan, layout, readCounter)
+			if err != nil {
+				return err
+			}
+			if err = field.codec.skip(d); err != nil {
+				return fieldError(err, field, start)
+			}
+		}
+
+		return nil
+	}
+}
+
+// bitsSkipper reads past a value always written with the given number of bits
+func bitsSkipper(bits int) skipperFunc {
+	return func(d *decodeState) error {
+		return coachwire.SkipBits(d.reader, bits)
+	}
+}
+
+func varIntegerSkipper(d *decodeState) error {
+	_, err := coachwire.ReadVarInteger(d.reader)
+	return err
+}
+
+func gammaIntegerSkipper(d *decodeState) error {
+	_, err := coachwire.ReadGammaInteger(d.reader)
+	return err
+}
+
+func addrSkipper(d *decodeState) error {
+	_, err := coachwire.ReadAddr(d.reader)
+	return err
+}
+
+func addrPortSkipper(d *decodeState) error {
+	_, err := coachwire.ReadAddrPort(d.reader)
+	return err
+}
+
+// bigIntSkipper reads past a *big.Int whose absolute value fits in maxBits bits
+func bigIntSkipper(maxBits int) skipperFunc {
+	return func(d *decodeState) error {
+		return coachwire.SkipBigInt(d.reader, maxBits)
+	}
+}
+
+// blobSkipper reads past the bytes written by blobEncoder
+func blobSkipper(maxLen int) skipperFunc {
+	return func(d *decodeState) error {
+		return coachwire.SkipBytes(d.reader, maxLen)
+	}
+}
+
+// optionalSkipper reads the presence bit of an Optional and past its value when the value is present
+func optionalSkipper(inner skipperFunc) skipperFunc {
+	return func(d *decodeState) error {
+		present, err := d.reader.Read(1)
+		if err != nil || present == 0 {
+			return err
+		}
+
+		return inner(d)
+	}
+}
+
+// scratchSkipper decodes into a temporary value of type rt since only its own methods know how many bits it takes
+func scratchSkipper(rt reflect.Type, decode decoderFunc) skipperFunc {
+	return func(d *decodeState) error {
+		return decode(d, reflect.New(rt).Elem())
+	}
+}
@@ -0,0 +1,102 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type routeHeader struct {
+	Kind   int32      `coachbuf:"1,min=0,max=10"`
+	TTL    int32      `coachbuf:"2,enc=gamma"`
+	Source netip.Addr `coachbuf:"3"`
+}
+
+// Validate fails for a header decoded without its source, so it must not be called on partially decoded headers
+func (h routeHeader) Validate() error {
+	if !h.Source.IsValid() {
+		return errors.New("header without source")
+	}
+	return nil
+}
+
+type routeMessage struct {
+	ID       int32                             `coachbuf:"1,enc=varint"`
+	Header   routeHeader                       `coachbuf:"2"`
+	Payload  blobBytes                         `coachbuf:"3,maxlen=64"`
+	Balance  *big.Int                          `coachbuf:"4,maxbits=64"`
+	Heading  Heading                           `coachbuf:"5"`
+	Extra    coachbuf.Optional[routeHeader]    `coachbuf:"6"`
+	Priority int32                             `coachbuf:"7,min=0,max=7"`
+	Backup   coachbuf.Optional[netip.AddrPort] `coachbuf:"8"`
+}
+
+func TestDecodeFields(t *testing.T) {
+	source := netip.MustParseAddr("2001:db8::1")
+	input := routeMessage{
+		ID:       -4242,
+		Header:   routeHeader{Kind: 3, TTL: 64, Source: source},
+		Payload:  blobBytes("payload"),
+		Balance:  big.NewInt(-1 << 40),
+		Heading:  90,
+		Extra:    coachbuf.Some(routeHeader{Kind: 9, TTL: -1, Source: source}),
+		Priority: 5,
+		Backup:   coachbuf.Some(netip.MustParseAddrPort("10.0.0.1:53")),
+	}
+	data, err := coachbuf.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	tests := []struct {
+		name string
+		mask coachbuf.FieldMask
+		want routeMessage
+	}{
+		{name: "top level fields and nested path", mask: coachbuf.Fields(1, 7, "Header.Kind"), want: routeMessage{ID: -4242, Header: routeHeader{Kind: 3}, Priority: 5}},
+		{name: "whole nested struct", mask: coachbuf.Fields("Header", "Header.Kind"), want: routeMessage{Header: input.Header}},
+		{name: "path within optional", mask: coachbuf.Fields("Extra.TTL"), want: routeMessage{Extra: coachbuf.Some(routeHeader{TTL: -1})}},
+		{name: "last field", mask: coachbuf.Fields(8), want: routeMessage{Backup: input.Backup}},
+		{name: "no field", mask: coachbuf.Fields(), want: routeMessage{}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var result routeMessage
+			if err := coachbuf.DecodeFields(data, &result, tt.mask); err != nil {
+				t.Fatalf("DecodeFields() = %v, want %v", err, nil)
+			}
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("DecodeFields() = %+v, want %+v", result, tt.want)
+			}
+		})
+	}
+
+	t.Run("invalid masks", func(t *testing.T) {
+		t.Parallel()
+
+		for _, mask := range []coachbuf.FieldMask{
+			coachbuf.Fields(9),
+			coachbuf.Fields(-1),
+			coachbuf.Fields("Header.Missing"),
+			coachbuf.Fields("ID.Kind"),
+			coachbuf.Fields(1.5),
+		} {
+			var result routeMessage
+			if err := coachbuf.DecodeFields(data, &result, mask); !errors.Is(err, coachbuf.ErrInvalidFieldMask) {
+				t.Errorf("DecodeFields() = %v, want %v", err, coachbuf.ErrInvalidFieldMask)
+			}
+		}
+
+		var result int32
+		if err := coachbuf.DecodeFields(data, &result, coachbuf.Fields(1)); !errors.Is(err, coachbuf.ErrInvalidFieldMask) {
+			t.Errorf("DecodeFields() = %v, want %v", err, coachbuf.ErrInvalidFieldMask)
+		}
+	})
+}
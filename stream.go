@@ -0,0 +1,105 @@
+package coachbuf
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// streamHeaderBytes is the size of the length prefix written before every message of a stream
+// the prefix is a single 32 bit word holding the number of 32 bit words of the message
+const streamHeaderBytes = 4
+
+// DefaultMaxMessageSize is the maximum number of bytes of a message read by a Decoder whose MaxMessageSize is zero
+const DefaultMaxMessageSize = 1 << 20
+
+// Encoder writes a sequence of self-delimiting Coachbuf messages to an io.Writer
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an Encoder that writes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode serializes v in Coachbuf format and writes it to the stream prefixed by its length
+func (e *Encoder) Encode(v any) error {
+	data, err := Encode(v)
+	if err != nil {
+		return err
+	}
+
+	header := bitpacker.NewWriter()
+	if err = header.Write(uint32(len(data)/4), 32); err != nil {
+		return fmt.Errorf("could not write message length: %w", ErrWriterInvalidState)
+	}
+	if err = header.FlushBits(); err != nil {
+		return fmt.Errorf("could not FlushBits() after writing: %w", ErrWriterInvalidState)
+	}
+
+	if _, err = e.w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+
+	return err
+}
+
+// Decoder reads a sequence of Coachbuf messages written by an Encoder from an io.Reader
+type Decoder struct {
+	// MaxMessageSize is the maximum number of bytes of a message, DefaultMaxMessageSize when zero
+	// a longer message is rejected with ErrMessageTooLarge before any of it is read, the stream can not be read further
+	MaxMessageSize int
+
+	r *bufio.Reader
+}
+
+// NewDecoder returns a Decoder that reads from r
+// the Decoder buffers its input and may read data from r beyond the messages decoded
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// More reports whether there is another message in the stream
+func (d *Decoder) More() bool {
+	_, err := d.r.Peek(1)
+	return err == nil
+}
+
+// Decode reads the next message from the stream and deserializes it into the value v
+// io.EOF is returned when the stream ends before a message, io.ErrUnexpectedEOF when it ends within one
+func (d *Decoder) Decode(v any) error {
+	var header [streamHeaderBytes]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		return err
+	}
+
+	numWords, err := bitpacker.NewReader(bytes.NewReader(header[:]), streamHeaderBytes).Read(32)
+	if err != nil {
+		return fmt.Errorf("could not read message length: %w", err)
+	}
+
+	maxSize := d.MaxMessageSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	if size := int64(numWords) * 4; size > int64(maxSize) {
+		return fmt.Errorf("length=%d bytes, limit=%d bytes: %w", size, maxSize, ErrMessageTooLarge)
+	}
+
+	// the message is copied as it arrives rather than allocated up front from an untrusted length
+	var data bytes.Buffer
+	if _, err = io.CopyN(&data, d.r, int64(numWords)*4); err != nil {
+		if errors.Is(err, io.EOF) {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+
+	return Decode(data.Bytes(), v)
+}
@@ -0,0 +1,91 @@
+package coachbuf_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+func TestEncoderDecoder(t *testing.T) {
+	type TestStruct struct {
+		Int32   int32   `coachbuf:"1,enc=varint"`
+		Float32 float32 `coachbuf:"2"`
+	}
+
+	t.Run("sequence of messages", func(t *testing.T) {
+		t.Parallel()
+
+		inputs := []TestStruct{{Int32: 1, Float32: 1.5}, {Int32: -20, Float32: 0}, {Int32: 1 << 30, Float32: -3.25}}
+
+		var buf bytes.Buffer
+		enc := coachbuf.NewEncoder(&buf)
+		for _, input := range inputs {
+			if err := enc.Encode(input); err != nil {
+				t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+			}
+		}
+
+		var results []TestStruct
+		dec := coachbuf.NewDecoder(&buf)
+		for dec.More() {
+			var result TestStruct
+			if err := dec.Decode(&result); err != nil {
+				t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			results = append(results, result)
+		}
+
+		if len(results) != len(inputs) {
+			t.Fatalf("More() decoded %v messages, want %v", len(results), len(inputs))
+		}
+		for i := range inputs {
+			if results[i] != inputs[i] {
+				t.Errorf("Decode() = %v, want %v", results[i], inputs[i])
+			}
+		}
+
+		var result TestStruct
+		if err := dec.Decode(&result); !errors.Is(err, io.EOF) {
+			t.Errorf("Decode() = %v, want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("truncated message", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		if err := coachbuf.NewEncoder(&buf).Encode(TestStruct{Int32: 1, Float32: 1.5}); err != nil {
+			t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		buf.Truncate(buf.Len() - 1)
+
+		var result TestStruct
+		if err := coachbuf.NewDecoder(&buf).Decode(&result); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Decode() = %v, want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("message larger than MaxMessageSize", func(t *testing.T) {
+		t.Parallel()
+
+		// a length prefix of 2^32-1 words is rejected without buffering the message
+		stream := bytes.NewReader([]byte{255, 255, 255, 255, 0, 0, 0, 0})
+		var result TestStruct
+		if err := coachbuf.NewDecoder(stream).Decode(&result); !errors.Is(err, coachbuf.ErrMessageTooLarge) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrMessageTooLarge)
+		}
+
+		var buf bytes.Buffer
+		if err := coachbuf.NewEncoder(&buf).Encode(TestStruct{Int32: 1, Float32: 1.5}); err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		dec := coachbuf.NewDecoder(&buf)
+		dec.MaxMessageSize = 4
+		if err := dec.Decode(&result); !errors.Is(err, coachbuf.ErrMessageTooLarge) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrMessageTooLarge)
+		}
+	})
+}
@@ -2,6 +2,11 @@ package coachbuf_test
 
 import (
 	"errors"
+	"math"
+	"math/big"
+	"math/cmplx"
+	"net/netip"
+	"net/url"
 	"testing"
 
 	"github.com/trphume/coachbuf"
@@ -76,6 +81,154 @@ func TestEncodeDecodeStruct(t *testing.T) {
 				t.Errorf("Decode() = %v, want %v", result.Int32, inputEncode.Int32)
 			}
 		})
+
+		t.Run("variable-length integers", func(t *testing.T) {
+			t.Parallel()
+
+			type TestStruct struct {
+				Varint int32 `coachbuf:"1,enc=varint"`
+				Gamma  int32 `coachbuf:"2,enc=gamma"`
+			}
+
+			inputEncode := TestStruct{Varint: -300, Gamma: 12}
+			inputDecode, err := coachbuf.Encode(inputEncode)
+			if err != nil {
+				t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			result := TestStruct{}
+			if err := coachbuf.Decode(inputDecode, &result); err != nil {
+				t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			if inputEncode != result {
+				t.Errorf("Decode() = %v, want %v", result, inputEncode)
+			}
+		})
+
+		t.Run("standard library types", func(t *testing.T) {
+			t.Parallel()
+
+			type TestStruct struct {
+				Addr     netip.Addr     `coachbuf:"1"`
+				AddrPort netip.AddrPort `coachbuf:"2"`
+				BigInt   *big.Int       `coachbuf:"3,maxbits=100"`
+			}
+
+			inputEncode := TestStruct{
+				Addr:     netip.MustParseAddr("10.1.2.3"),
+				AddrPort: netip.MustParseAddrPort("[fe80::1]:9000"),
+				BigInt:   new(big.Int).Lsh(big.NewInt(-3), 90),
+			}
+			inputDecode, err := coachbuf.Encode(inputEncode)
+			if err != nil {
+				t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			result := TestStruct{}
+			if err := coachbuf.Decode(inputDecode, &result); err != nil {
+				t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			switch {
+			case inputEncode.Addr != result.Addr:
+				t.Errorf("Decode() = %v, want %v", result.Addr, inputEncode.Addr)
+			case inputEncode.AddrPort != result.AddrPort:
+				t.Errorf("Decode() = %v, want %v", result.AddrPort, inputEncode.AddrPort)
+			case inputEncode.BigInt.Cmp(result.BigInt) != 0:
+				t.Errorf("Decode() = %v, want %v", result.BigInt, inputEncode.BigInt)
+			}
+		})
+
+		t.Run("absent addresses", func(t *testing.T) {
+			t.Parallel()
+
+			// the zero netip.Addr and zoned addresses can not be written, an absent address is an Optional
+			type TestStruct struct {
+				Addr     coachbuf.Optional[netip.Addr]     `coachbuf:"1"`
+				AddrPort coachbuf.Optional[netip.AddrPort] `coachbuf:"2"`
+				Port     int32                             `coachbuf:"3,min=0,max=65535"`
+			}
+
+			inputEncode := TestStruct{Port: 80}
+			inputDecode, err := coachbuf.Encode(inputEncode)
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			result := TestStruct{Addr: coachbuf.Some(netip.MustParseAddr("10.1.2.3"))}
+			if err := coachbuf.Decode(inputDecode, &result); err != nil {
+				t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			if inputEncode != result {
+				t.Errorf("Decode() = %v, want %v", result, inputEncode)
+			}
+
+			for _, value := range []netip.Addr{{}, netip.MustParseAddr("fe80::1%eth0")} {
+				if _, err := coachbuf.Encode(TestStruct{Addr: coachbuf.Some(value)}); err == nil {
+					t.Errorf("Encode() = %v, want an error", err)
+				}
+			}
+		})
+
+		t.Run("url.URL through the blob fallback", func(t *testing.T) {
+			t.Parallel()
+
+			// url.URL is not a native type, it is written as a blob through its encoding.BinaryMarshaler
+			type TestStruct struct {
+				Link     url.URL  `coachbuf:"1,maxlen=128"`
+				Callback *url.URL `coachbuf:"2,maxlen=128"`
+			}
+
+			link, err := url.Parse("https://example.com/match?id=42#score")
+			if err != nil {
+				t.Fatalf("Parse() = %v, want %v", err.Error(), nil)
+			}
+			callback, err := url.Parse("wss://example.com:8443/live")
+			if err != nil {
+				t.Fatalf("Parse() = %v, want %v", err.Error(), nil)
+			}
+
+			inputDecode, err := coachbuf.Encode(TestStruct{Link: *link, Callback: callback})
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			var result TestStruct
+			if err := coachbuf.Decode(inputDecode, &result); err != nil {
+				t.Fatalf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			if result.Link.String() != link.String() || result.Callback == nil || result.Callback.String() != callback.String() {
+				t.Errorf("Decode() = %v, %v, want %v, %v", &result.Link, result.Callback, link, callback)
+			}
+		})
+
+		t.Run("complex numbers", func(t *testing.T) {
+			t.Parallel()
+
+			type TestStruct struct {
+				Complex64  complex64  `coachbuf:"1"`
+				Complex128 complex128 `coachbuf:"2"`
+				Compressed complex64  `coachbuf:"3,min=-1,max=1,res=0.001"`
+			}
+
+			inputEncode := TestStruct{Complex64: complex(1.5, -2.25), Complex128: complex(math.Pi, math.E), Compressed: complex(0.5, -0.25)}
+			inputDecode, err := coachbuf.Encode(inputEncode)
+			if err != nil {
+				t.Errorf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			result := TestStruct{}
+			if err := coachbuf.Decode(inputDecode, &result); err != nil {
+				t.Errorf("Decode() = %v, want %v", err.Error(), nil)
+			}
+			switch {
+			case inputEncode.Complex64 != result.Complex64:
+				t.Errorf("Decode() = %v, want %v", result.Complex64, inputEncode.Complex64)
+			case inputEncode.Complex128 != result.Complex128:
+				t.Errorf("Decode() = %v, want %v", result.Complex128, inputEncode.Complex128)
+			case cmplx.Abs(complex128(inputEncode.Compressed-result.Compressed)) > 0.001:
+				t.Errorf("Decode() = %v, want %v", result.Compressed, inputEncode.Compressed)
+			}
+		})
 	})
 
 	t.Run("Encode", func(t *testing.T) {
@@ -161,6 +314,76 @@ func TestEncodeDecodeStruct(t *testing.T) {
 			}
 		})
 
+		t.Run("enc tag unknown value", func(t *testing.T) {
+			t.Parallel()
+
+			input := struct {
+				Int32 int32 `coachbuf:"1,enc=zigzag"`
+			}{Int32: 10000}
+			want := coachbuf.ErrInvalidTagFormat
+
+			_, err := coachbuf.Encode(input)
+			if !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err.Error(), want)
+			}
+		})
+
+		t.Run("enc tag combined with min/max", func(t *testing.T) {
+			t.Parallel()
+
+			input := struct {
+				Int32 int32 `coachbuf:"1,enc=varint,max=100"`
+			}{Int32: 10}
+			want := coachbuf.ErrInvalidTagFormat
+
+			_, err := coachbuf.Encode(input)
+			if !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err.Error(), want)
+			}
+		})
+
+		t.Run("maxbits tag missing for big.Int", func(t *testing.T) {
+			t.Parallel()
+
+			input := struct {
+				BigInt *big.Int `coachbuf:"1"`
+			}{BigInt: big.NewInt(1)}
+			want := coachbuf.ErrInvalidTagFormat
+
+			_, err := coachbuf.Encode(input)
+			if !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err.Error(), want)
+			}
+		})
+
+		t.Run("res tag without min/max", func(t *testing.T) {
+			t.Parallel()
+
+			input := struct {
+				Complex64 complex64 `coachbuf:"1,max=1,res=0.01"`
+			}{Complex64: complex(0.5, 0.5)}
+			want := coachbuf.ErrInvalidTagFormat
+
+			_, err := coachbuf.Encode(input)
+			if !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err.Error(), want)
+			}
+		})
+
+		t.Run("res tag with more steps than 32 bits hold", func(t *testing.T) {
+			t.Parallel()
+
+			input := struct {
+				Complex64 complex64 `coachbuf:"1,min=-1000000,max=1000000,res=0.000001"`
+			}{Complex64: complex(12345, -500)}
+			want := coachbuf.ErrInvalidTagFormat
+
+			_, err := coachbuf.Encode(input)
+			if !errors.Is(err, want) {
+				t.Errorf("Encode() = %v, want %v", err, want)
+			}
+		})
+
 		t.Run("unsupported type", func(t *testing.T) {
 			t.Parallel()
 
@@ -0,0 +1,14 @@
+package coachbuf
+
+import (
+	"math/big"
+	"net/netip"
+	"reflect"
+)
+
+// standard library types with built-in support, these are matched before the kind of the value
+var (
+	addrType     = reflect.TypeOf(netip.Addr{})
+	addrPortType = reflect.TypeOf(netip.AddrPort{})
+	bigIntType   = reflect.TypeOf((*big.Int)(nil))
+)
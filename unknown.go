@@ -0,0 +1,116 @@
+package coachbuf
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+)
+
+// Unknown holds the raw bits of fields not known to a struct so that they survive a decode and encode round trip
+//
+// A struct opts in by declaring an exported field of type Unknown without a tag. Decoding the struct as a top level
+// value captures every bit following its known fields and encoding writes them back verbatim after the known fields.
+// This requires newer versions of the struct to be append only: a new field must be declared after every existing
+// field so that it is written last, existing fields must not be removed, reordered or changed. Outside versioned
+// mode the struct must be the top level value, ErrNestedUnknown is returned when it is nested in another value.
+// In versioned mode the fields with an ordering number unknown to the struct are captured at any depth instead,
+// and are only written back in versioned mode
+type Unknown struct {
+	words   []uint32
+	numBits int
+	fields  int // number of fields captured in versioned mode, zero for the trailing bits of a top level struct
+}
+
+// NumBits returns the number of raw bits held, including the padding of the payload they were decoded from
+// when captured from the trailing bits of a top level struct
+func (u Unknown) NumBits() int {
+	return u.numBits
+}
+
+var unknownType = reflect.TypeOf(Unknown{})
+
+// noUnknownField is the unknown index of a structPlan whose struct does not declare an Unknown field
+const noUnknownField = -1
+
+// compileUnknown sets the Unknown field of plan to the struct field at index i
+func compileUnknown(plan *structPlan, structField reflect.StructField, structTag string, i int) error {
+	if structTag != "" {
+		return fmt.Errorf("field=%s of type Unknown can not be tagged: %w", structField.Name, ErrInvalidTagFormat)
+	}
+	if !structField.IsExported() {
+		return fmt.Errorf("field=%s must be exported to be decoded: %w", structField.Name, ErrUnsupportedType)
+	}
+	if plan.unknown != noUnknownField {
+		return fmt.Errorf("field=%s is the second Unknown field of type=%v: %w", structField.Name, plan.typ, ErrUnsupportedType)
+	}
+	plan.unknown = i
+
+	return nil
+}
+
+// writeUnknown writes the raw bits held by u, which must have been captured in the same layout it is written in
+func writeUnknown(e *encodeState, u Unknown, layout structLayout) error {
+	if u.fields > 0 && layout != layoutVersioned {
+		return fmt.Errorf("%d unknown fields captured in versioned mode: %w", u.fields, ErrLayoutMismatch)
+	}
+	if u.fields == 0 && u.numBits > 0 && layout == layoutVersioned {
+		return fmt.Errorf("%d unknown trailing bits in versioned mode: %w", u.numBits, ErrLayoutMismatch)
+	}
+
+	return writeWords(e.writer, u.words, u.numBits)
+}
+
+// readUnknown reads every remaining bit into the Unknown field rv
+func readUnknown(d *decodeState, rv reflect.Value) error {
+	numBits := d.reader.NumBitsRemaining()
+	if err := d.alloc((numBits + 7) / 8); err != nil {
+		return err
+	}
+
+	words, err := readWords(d.reader, numBits)
+	if err != nil {
+		return err
+	}
+
+	return setValue(rv, reflect.ValueOf(Unknown{words: words, numBits: numBits}))
+}
+
+// readWords reads numBits bits as 32 bit words, the last word holds the remaining bits
+func readWords(reader *bitpacker.Reader, numBits int) ([]uint32, error) {
+	if numBits > reader.NumBitsRemaining() {
+		return nil, fmt.Errorf("bits=%d exceeds remaining bits=%d: %w", numBits, reader.NumBitsRemaining(), bitpacker.ErrBitsReadExceeded)
+	}
+
+	words := make([]uint32, 0, (numBits+31)/32)
+	for numBits > 0 {
+		bits := numBits
+		if bits > 32 {
+			bits = 32
+		}
+
+		word, err := reader.Read(bits)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+		numBits -= bits
+	}
+
+	return words, nil
+}
+
+// writeWords writes numBits bits of words read by readWords
+func writeWords(writer *bitpacker.Writer, words []uint32, numBits int) error {
+	for i, word := range words {
+		bits := numBits - i*32
+		if bits > 32 {
+			bits = 32
+		}
+		if err := writer.Write(word, bits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package coachbuf_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type unknownStatsV1 struct {
+	Level int32 `coachbuf:"1,min=0,max=100"`
+}
+
+type unknownStatsV2 struct {
+	Level int32 `coachbuf:"1,min=0,max=100"`
+	Rank  int32 `coachbuf:"2,min=0,max=10"`
+}
+
+// unknownPlayerV1 is an older version of unknownPlayerV2 preserving the fields it does not know
+type unknownPlayerV1 struct {
+	Health  int32          `coachbuf:"1,min=0,max=100"`
+	Stats   unknownStatsV1 `coachbuf:"2"`
+	Unknown coachbuf.Unknown
+}
+
+type unknownPlayerV2 struct {
+	Health int32                    `coachbuf:"1,min=0,max=100"`
+	Stats  unknownStatsV1           `coachbuf:"2"`
+	Score  int32                    `coachbuf:"3,enc=varint"`
+	Guild  unknownStatsV2           `coachbuf:"4"`
+	Shield coachbuf.Optional[int32] `coachbuf:"5,min=0,max=10"`
+}
+
+func TestUnknown(t *testing.T) {
+	t.Run("round trip through older version", func(t *testing.T) {
+		t.Parallel()
+
+		input := unknownPlayerV2{
+			Health: 80,
+			Stats:  unknownStatsV1{Level: 12},
+			Score:  -123456,
+			Guild:  unknownStatsV2{Level: 99, Rank: 3},
+			Shield: coachbuf.Some[int32](7),
+		}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var proxy unknownPlayerV1
+		if err = coachbuf.Decode(data, &proxy); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if proxy.Health != input.Health || proxy.Stats != input.Stats || proxy.Unknown.NumBits() == 0 {
+			t.Errorf("Decode() = %+v, want known fields of %+v and unknown bits", proxy, input)
+		}
+
+		proxy.Health = 60
+		reencoded, err := coachbuf.Encode(proxy)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if len(reencoded) != len(data) {
+			t.Errorf("Encode() = %v, want %d bytes", reencoded, len(data))
+		}
+
+		var result unknownPlayerV2
+		if err = coachbuf.Decode(reencoded, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		want := input
+		want.Health = 60
+		if result != want {
+			t.Errorf("Decode() = %+v, want %+v", result, want)
+		}
+	})
+
+	t.Run("identical bytes", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := coachbuf.Encode(unknownPlayerV2{Health: 1, Score: 1 << 20, Guild: unknownStatsV2{Rank: 10}})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var proxy unknownPlayerV1
+		if err = coachbuf.Decode(data, &proxy); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		reencoded, err := coachbuf.Encode(proxy)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if !bytes.Equal(reencoded, data) {
+			t.Errorf("Encode() = %v, want %v", reencoded, data)
+		}
+	})
+
+	t.Run("nested struct", func(t *testing.T) {
+		t.Parallel()
+
+		type Outer struct {
+			Inner unknownPlayerV1 `coachbuf:"1"`
+			Level int32           `coachbuf:"2,min=0,max=100"`
+		}
+
+		// bits following a nested struct belong to its parent so the Unknown field could not be filled
+		input := Outer{Inner: unknownPlayerV1{Health: 5}, Level: 42}
+		if _, err := coachbuf.Encode(input); !errors.Is(err, coachbuf.ErrNestedUnknown) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrNestedUnknown)
+		}
+		type Plain struct {
+			Inner unknownStatsV1 `coachbuf:"1"`
+			Level int32          `coachbuf:"2,min=0,max=100"`
+		}
+		plain, err := coachbuf.Encode(Plain{Level: 42})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		if err := coachbuf.Decode(plain, &Outer{}); !errors.Is(err, coachbuf.ErrNestedUnknown) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrNestedUnknown)
+		}
+
+		// in versioned mode the unknown fields of a nested struct are captured
+		data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		var result Outer
+		if err = (coachbuf.DecodeOptions{Versioned: true}).Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if result.Level != input.Level || result.Inner.Health != input.Inner.Health {
+			t.Errorf("Decode() = %+v, want %+v", result, input)
+		}
+	})
+
+	t.Run("invalid declarations", func(t *testing.T) {
+		t.Parallel()
+
+		type Tagged struct {
+			Unknown coachbuf.Unknown `coachbuf:"1"`
+		}
+		type Twice struct {
+			First  coachbuf.Unknown
+			Second coachbuf.Unknown
+		}
+
+		if _, err := coachbuf.NewCodec[Tagged](); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+		if _, err := coachbuf.NewCodec[Twice](); !errors.Is(err, coachbuf.ErrUnsupportedType) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrUnsupportedType)
+		}
+		if maxBits, err := coachbuf.MaxBits[unknownPlayerV1](); err != nil || maxBits != -1 {
+			t.Errorf("MaxBits() = %v, %v, want %v", maxBits, err, -1)
+		}
+	})
+}
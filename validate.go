@@ -0,0 +1,127 @@
+package coachbuf
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Validator is implemented by structs checking their own invariants
+//
+// Validate is called on every decoded struct, including nested ones, once all of its fields are read.
+// An error returned by Validate fails the decode
+type Validator interface {
+	Validate() error
+}
+
+// ErrConstraintViolated indicates that a value does not satisfy the nonzero, oneof or minlen tag of its field
+var ErrConstraintViolated = errors.New("constraint violated")
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// checkFunc returns an error when the value of a field does not satisfy the constraint tags of the field
+type checkFunc func(rv reflect.Value) error
+
+// compileConstraints returns the check of the constraint tags given to a field of type rt, nil when there are none
+// the constraints of an Optional field apply to its value and are only checked when the value is present
+func compileConstraints(rt reflect.Type, tags []string) (checkFunc, error) {
+	c, found, err := getConstraintTags(tags)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	if isOptional(rt) {
+		inner, err := constraintCheck(rt.Field(optionalValueField).Type, c)
+		if err != nil {
+			return nil, err
+		}
+		return func(rv reflect.Value) error {
+			if !rv.Field(optionalValidField).Bool() {
+				return nil
+			}
+			return inner(rv.Field(optionalValueField))
+		}, nil
+	}
+
+	return constraintCheck(rt, c)
+}
+
+// constraintCheck returns the check of the constraints c for values of type rt
+func constraintCheck(rt reflect.Type, c fieldConstraints) (checkFunc, error) {
+	var oneOf []float64
+	for _, value := range c.oneOf {
+		var f float64
+		var err error
+		switch rt.Kind() {
+		case reflect.Int32:
+			var i int64
+			i, err = strconv.ParseInt(value, 10, 32)
+			f = float64(i)
+		case reflect.Float32:
+			f, err = strconv.ParseFloat(value, 32)
+		default:
+			return nil, fmt.Errorf("oneof tag requires an int32 or float32 value, type=%v: %w", rt, ErrInvalidTagFormat)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("oneof tag values must be %v numbers, value=%s: %w", rt, value, ErrInvalidTagFormat)
+		}
+		oneOf = append(oneOf, f)
+	}
+
+	if c.minLen > 0 {
+		switch rt.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		default:
+			return nil, fmt.Errorf("minlen tag requires a string, slice or map value, type=%v: %w", rt, ErrInvalidTagFormat)
+		}
+	}
+
+	return func(rv reflect.Value) error {
+		if c.nonZero && rv.IsZero() {
+			return fmt.Errorf("value of type=%v must be nonzero: %w", rv.Type(), ErrConstraintViolated)
+		}
+		if oneOf != nil && !containsNumber(oneOf, rv) {
+			return fmt.Errorf("value=%v must be one of %v: %w", rv.Interface(), c.oneOf, ErrConstraintViolated)
+		}
+		if c.minLen > 0 && rv.Len() < c.minLen {
+			return fmt.Errorf("length=%d must be at least minlen=%d: %w", rv.Len(), c.minLen, ErrConstraintViolated)
+		}
+
+		return nil
+	}, nil
+}
+
+// containsNumber reports whether the int32 or float32 rv is equal to one of values
+func containsNumber(values []float64, rv reflect.Value) bool {
+	var v float64
+	if rv.Kind() == reflect.Int32 {
+		v = float64(rv.Int())
+	} else {
+		v = rv.Float()
+	}
+
+	for _, value := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate calls the Validate method of a decoded struct
+func validate(rv reflect.Value) error {
+	var v Validator
+	if rv.CanAddr() {
+		v = rv.Addr().Interface().(Validator)
+	} else {
+		v = rv.Interface().(Validator)
+	}
+
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("type=%v failed validation: %w", rv.Type(), err)
+	}
+
+	return nil
+}
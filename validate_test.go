@@ -0,0 +1,165 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+var errNegativeBalance = errors.New("negative balance")
+
+type validatedAccount struct {
+	Balance int32 `coachbuf:"1,min=-100,max=100"`
+}
+
+func (a *validatedAccount) Validate() error {
+	if a.Balance < 0 {
+		return errNegativeBalance
+	}
+	return nil
+}
+
+type validatedBank struct {
+	Accounts int32            `coachbuf:"1,min=0,max=10"`
+	Main     validatedAccount `coachbuf:"2"`
+}
+
+func (b validatedBank) Validate() error {
+	if b.Accounts == 0 {
+		return errors.New("bank without accounts")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input validatedBank
+		path  string
+		err   error
+	}{
+		{name: "valid", input: validatedBank{Accounts: 1, Main: validatedAccount{Balance: 10}}},
+		{name: "nested struct invalid", input: validatedBank{Accounts: 1, Main: validatedAccount{Balance: -1}}, path: "validatedBank.Main", err: errNegativeBalance},
+		{name: "top level struct invalid", input: validatedBank{Accounts: 0}, path: "validatedBank"},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Validate is only called when decoding
+			data, err := coachbuf.Encode(tt.input)
+			if err != nil {
+				t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+			}
+
+			var result validatedBank
+			err = coachbuf.Decode(data, &result)
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("Decode() = %v, want %v", err, nil)
+				}
+				return
+			}
+
+			var e *coachbuf.Error
+			if !errors.As(err, &e) || e.Path != tt.path {
+				t.Fatalf("Decode() = %v, want path %v", err, tt.path)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("Decode() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// constrainedNames is a slice written as a blob so that minlen can be applied to it
+type constrainedNames []byte
+
+func (n constrainedNames) MarshalBinary() ([]byte, error) { return n, nil }
+
+func (n *constrainedNames) UnmarshalBinary(data []byte) error {
+	*n = data
+	return nil
+}
+
+type constrainedMessage struct {
+	Kind   int32                      `coachbuf:"1,min=0,max=10,oneof=1|2|4"`
+	ID     int32                      `coachbuf:"2,enc=varint,nonzero"`
+	Scale  coachbuf.Optional[float32] `coachbuf:"3,oneof=0.5|1"`
+	Prefix constrainedNames           `coachbuf:"4,maxlen=8,minlen=2"`
+}
+
+func TestConstraints(t *testing.T) {
+	valid := constrainedMessage{Kind: 2, ID: 7, Scale: coachbuf.Some[float32](0.5), Prefix: constrainedNames("ab")}
+
+	t.Run("Encode", func(t *testing.T) {
+		t.Parallel()
+
+		tests := []struct {
+			name  string
+			input constrainedMessage
+			err   error
+		}{
+			{name: "valid", input: valid, err: nil},
+			{name: "absent optional", input: constrainedMessage{Kind: 1, ID: 1, Prefix: constrainedNames("ab")}, err: nil},
+			{name: "oneof", input: constrainedMessage{Kind: 3, ID: 7, Prefix: constrainedNames("ab")}, err: coachbuf.ErrConstraintViolated},
+			{name: "nonzero", input: constrainedMessage{Kind: 2, Prefix: constrainedNames("ab")}, err: coachbuf.ErrConstraintViolated},
+			{name: "optional oneof", input: constrainedMessage{Kind: 2, ID: 7, Scale: coachbuf.Some[float32](2), Prefix: constrainedNames("ab")}, err: coachbuf.ErrConstraintViolated},
+			{name: "minlen", input: constrainedMessage{Kind: 2, ID: 7, Prefix: constrainedNames("a")}, err: coachbuf.ErrConstraintViolated},
+		}
+		for _, tt := range tests {
+			if _, err := coachbuf.Encode(tt.input); !errors.Is(err, tt.err) {
+				t.Errorf("Encode() %s = %v, want %v", tt.name, err, tt.err)
+			}
+		}
+	})
+
+	t.Run("Decode", func(t *testing.T) {
+		t.Parallel()
+
+		type unconstrainedMessage struct {
+			Kind   int32                      `coachbuf:"1,min=0,max=10"`
+			ID     int32                      `coachbuf:"2,enc=varint"`
+			Scale  coachbuf.Optional[float32] `coachbuf:"3"`
+			Prefix constrainedNames           `coachbuf:"4,maxlen=8"`
+		}
+
+		data, err := coachbuf.Encode(unconstrainedMessage(valid))
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		var result constrainedMessage
+		if err = coachbuf.Decode(data, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err, nil)
+		}
+
+		data, err = coachbuf.Encode(unconstrainedMessage{Kind: 5, ID: 7, Prefix: constrainedNames("ab")})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+		var e *coachbuf.Error
+		if err = coachbuf.Decode(data, &result); !errors.As(err, &e) || e.Path != "constrainedMessage.Kind" || !errors.Is(err, coachbuf.ErrConstraintViolated) {
+			t.Errorf("Decode() = %v, want %v at constrainedMessage.Kind", err, coachbuf.ErrConstraintViolated)
+		}
+	})
+
+	t.Run("invalid tags", func(t *testing.T) {
+		t.Parallel()
+
+		type OneOfNotNumber struct {
+			Kind int32 `coachbuf:"1,oneof=a|b"`
+		}
+		type MinLenOnInt struct {
+			Kind int32 `coachbuf:"1,minlen=1"`
+		}
+
+		if _, err := coachbuf.NewCodec[OneOfNotNumber](); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+		if _, err := coachbuf.NewCodec[MinLenOnInt](); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("NewCodec() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+	})
+}
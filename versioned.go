@@ -0,0 +1,288 @@
+package coachbuf
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/trphume/coachbuf/internal/bitpacker"
+	"github.com/trphume/coachbuf/internal/encoding/coachwire"
+)
+
+// structLayout is the way the fields of a struct are laid out on the wire
+type structLayout int
+
+const (
+	// layoutTagged writes the ordering number of every field followed by its value
+	layoutTagged structLayout = iota
+
+	// layoutPositional writes the values sorted by ordering number without their ordering numbers
+	layoutPositional
+
+	// layoutVersioned writes the number of fields, then the ordering number, the value header and the value of every field
+	layoutVersioned
+)
+
+// layout returns the layout of the fields of plan, the option of the struct takes precedence over the options of the call
+func (plan *structPlan) layout(positional, versioned bool) structLayout {
+	switch {
+	case plan.opts.positional:
+		return layoutPositional
+	case plan.opts.versioned:
+		return layoutVersioned
+	case positional:
+		return layoutPositional
+	case versioned:
+		return layoutVersioned
+	default:
+		return layoutTagged
+	}
+}
+
+// a value header starts with a single bit wire type followed by the width of a fixed width value or the length of any other value
+const (
+	wireTypeFixed  = 0
+	wireTypeLength = 1
+
+	// widthBits is the number of bits of the width of a fixed width value, written minus one
+	widthBits = 5
+)
+
+// fieldCountBits is the number of bits of the field count preceding the fields of a versioned struct
+var fieldCountBits = coachwire.IntegerBits(0, cbMaxOrderingNumber+1)
+
+// fixedWidth returns the number of bits of a value always written with the same number of bits, at most 32
+func fixedWidth(info codecInfo) (int, bool) {
+	if info.plan != nil || info.minBits != info.maxBits || info.maxBits < 1 || info.maxBits > 32 {
+		return 0, false
+	}
+
+	return info.maxBits, true
+}
+
+// valueHeaderBits returns the minimum and maximum number of bits of the value header of a value described by info
+func valueHeaderBits(info codecInfo) (int, int) {
+	if _, ok := fixedWidth(info); ok {
+		return 1 + widthBits, 1 + widthBits
+	}
+
+	minBits := 1 + coachwire.GammaIntegerBits(int32(info.minBits))
+	if info.maxBits == unboundedBits {
+		return minBits, unboundedBits
+	}
+
+	return minBits, 1 + coachwire.GammaIntegerBits(int32(info.maxBits))
+}
+
+// writeValueHeader writes the header of a value of numBits bits, as a width when fixed or as a length otherwise
+func writeValueHeader(writer *bitpacker.Writer, fixed bool, numBits int) error {
+	if fixed {
+		if err := writer.Write(wireTypeFixed, 1); err != nil {
+			return err
+		}
+		return writer.Write(uint32(numBits-1), widthBits)
+	}
+
+	if err := writer.Write(wireTypeLength, 1); err != nil {
+		return err
+	}
+	return coachwire.WriteGammaInteger(writer, int32(numBits))
+}
+
+// readValueHeader reads the header of a value and returns whether it has a fixed width and its number of bits
+func readValueHeader(reader *bitpacker.Reader) (bool, int, error) {
+	wireType, err := reader.Read(1)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if wireType == wireTypeFixed {
+		width, err := reader.Read(widthBits)
+		if err != nil {
+			return false, 0, err
+		}
+		return true, int(width) + 1, nil
+	}
+
+	length, err := coachwire.ReadGammaInteger(reader)
+	if err != nil {
+		return false, 0, err
+	}
+	if length < 0 || int(length) > reader.NumBitsRemaining() {
+		return false, 0, fmt.Errorf("length=%d bits, remaining=%d bits: %w", length, reader.NumBitsRemaining(), ErrFieldLength)
+	}
+
+	return false, int(length), nil
+}
+
+// writeVersionedValue writes the value header of rv followed by rv, a value without a fixed width is encoded once
+// into a scratch writer whose bits are copied after the header, encoding it twice to measure it first would double
+// the cost at every level of nesting
+func writeVersionedValue(e *encodeState, c codec, rv reflect.Value) error {
+	if width, ok := fixedWidth(c.info); ok {
+		if err := writeValueHeader(e.writer, true, width); err != nil {
+			return err
+		}
+		return c.encode(e, rv)
+	}
+
+	scratch := &encodeState{writer: bitpacker.NewWriter(), positional: e.positional, versioned: e.versioned, depth: e.depth}
+	if err := c.encode(scratch, rv); err != nil {
+		return err
+	}
+	numBits := scratch.writer.NumBitsWritten()
+	words, err := flushWords(scratch.writer)
+	if err != nil {
+		return err
+	}
+	if err := writeValueHeader(e.writer, false, numBits); err != nil {
+		return err
+	}
+
+	return writeWords(e.writer, words, numBits)
+}
+
+// decodeVersioned reads the field count of a versioned struct and every field following it, in any order
+// fields unknown to plan are captured by its Unknown field or skipped using the length in their value header
+func decodeVersioned(d *decodeState, plan *structPlan, rv reflect.Value, selection *fieldSelection) error {
+	start := d.reader.NumBitsRead()
+	count, err := coachwire.ReadInteger(d.reader, 0, cbMaxOrderingNumber+1)
+	if err != nil {
+		return &Error{Order: -1, BitOffset: start, Err: fmt.Errorf("error reading field count: %w", err)}
+	}
+
+	var captured *bitpacker.Writer
+	if plan.unknown != noUnknownField {
+		captured = bitpacker.NewWriter()
+	}
+
+	var seen [cbMaxOrderingNumber + 1]bool
+	var unknownFields int
+	for readCounter := 0; readCounter < int(count); readCounter++ {
+		start = d.reader.NumBitsRead()
+		order, err := coachwire.ReadInteger(d.reader, cbMinOrderingNumber, cbMaxOrderingNumber)
+		if err != nil {
+			return &Error{Order: -1, BitOffset: start, Err: fmt.Errorf("error reading ordering number: %w", err)}
+		}
+		fixed, numBits, err := readValueHeader(d.reader)
+		if err != nil {
+			return &Error{Order: int(order), BitOffset: start, Err: fmt.Errorf("error reading value header: %w", err)}
+		}
+
+		fieldNumber := plan.orderToField[order]
+		if fieldNumber == 0 {
+			if captured != nil {
+				err = captureField(d, captured, order, fixed, numBits)
+				unknownFields++
+			} else {
+				err = coachwire.SkipBits(d.reader, numBits)
+			}
+			if err != nil {
+				return &Error{Order: int(order), BitOffset: start, Err: err}
+			}
+			continue
+		}
+
+		field := &plan.fields[fieldNumber-1]
+		if err = checkDuplicate(d, plan, &seen, field.order, start); err != nil {
+			return err
+		}
+		if selection != nil {
+			child, ok := selection.fields[field.order]
+			if !ok {
+				if err = coachwire.SkipBits(d.reader, numBits); err != nil {
+					return fieldError(err, field, start)
+				}
+				continue
+			}
+			d.selection = child
+		}
+
+		if _, ok := fixedWidth(field.codec.info); d.strict && fixed != ok {
+			return fieldError(fmt.Errorf("fixed width=%t, want %t: %w", fixed, ok, ErrNonCanonical), field, start)
+		}
+
+		valueStart := d.reader.NumBitsRead()
+		if err = decodeField(d, field, rv, start); err != nil {
+			return err
+		}
+		if read := d.reader.NumBitsRead() - valueStart; read != numBits {
+			return fieldError(fmt.Errorf("read=%d bits, length=%d bits: %w", read, numBits, ErrFieldLength), field, start)
+		}
+	}
+
+	if captured == nil {
+		return nil
+	}
+
+	return setUnknown(rv.Field(plan.unknown), captured, unknownFields)
+}
+
+// captureField writes the ordering number, the value header and the raw bits of a field unknown to a struct to captured
+func captureField(d *decodeState, captured *bitpacker.Writer, order int32, fixed bool, numBits int) error {
+	if err := d.alloc((orderingBits + numBits + 7) / 8); err != nil {
+		return err
+	}
+
+	if err := coachwire.WriteInteger(captured, order, cbMinOrderingNumber, cbMaxOrderingNumber); err != nil {
+		return err
+	}
+	if err := writeValueHeader(captured, fixed, numBits); err != nil {
+		return err
+	}
+
+	words, err := readWords(d.reader, numBits)
+	if err != nil {
+		return err
+	}
+
+	return writeWords(captured, words, numBits)
+}
+
+// setUnknown sets the Unknown field rv to the numFields fields captured by decodeVersioned
+func setUnknown(rv reflect.Value, captured *bitpacker.Writer, numFields int) error {
+	numBits := captured.NumBitsWritten()
+	words, err := flushWords(captured)
+	if err != nil {
+		return err
+	}
+
+	return setValue(rv, reflect.ValueOf(Unknown{words: words, numBits: numBits, fields: numFields}))
+}
+
+// flushWords flushes w and returns the bits written to it as words read by readWords
+func flushWords(w *bitpacker.Writer) ([]uint32, error) {
+	numBits := w.NumBitsWritten()
+	if err := w.FlushBits(); err != nil {
+		return nil, err
+	}
+
+	data := w.Bytes()
+	return readWords(bitpacker.NewReader(bytes.NewReader(data), len(data)), numBits)
+}
+
+// skipVersioned reads past the fields of a versioned struct using the length in their value header
+func skipVersioned(d *decodeState) error {
+	start := d.reader.NumBitsRead()
+	count, err := coachwire.ReadInteger(d.reader, 0, cbMaxOrderingNumber+1)
+	if err != nil {
+		return &Error{Order: -1, BitOffset: start, Err: fmt.Errorf("error reading field count: %w", err)}
+	}
+
+	for readCounter := 0; readCounter < int(count); readCounter++ {
+		start = d.reader.NumBitsRead()
+		order, err := coachwire.ReadInteger(d.reader, cbMinOrderingNumber, cbMaxOrderingNumber)
+		if err != nil {
+			return &Error{Order: -1, BitOffset: start, Err: fmt.Errorf("error reading ordering number: %w", err)}
+		}
+		_, numBits, err := readValueHeader(d.reader)
+		if err == nil {
+			err = coachwire.SkipBits(d.reader, numBits)
+		}
+		if err != nil {
+			return &Error{Order: int(order), BitOffset: start, Err: err}
+		}
+	}
+
+	return nil
+}
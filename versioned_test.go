@@ -0,0 +1,249 @@
+package coachbuf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/trphume/coachbuf"
+)
+
+type versionedStatsV1 struct {
+	Health int32 `coachbuf:"1,min=0,max=100"`
+}
+
+type versionedStatsV2 struct {
+	Health int32     `coachbuf:"1,min=0,max=100"`
+	Mana   int32     `coachbuf:"2,enc=varint"`
+	Buffs  blobBytes `coachbuf:"3,maxlen=16"`
+}
+
+// versionedPlayerV1 is an older version of versionedPlayerV2 preserving the fields it does not know
+type versionedPlayerV1 struct {
+	ID      int32            `coachbuf:"1,enc=gamma"`
+	Stats   versionedStatsV1 `coachbuf:"3"`
+	Unknown coachbuf.Unknown
+}
+
+type versionedPlayerV2 struct {
+	ID    int32                    `coachbuf:"1,enc=gamma"`
+	Score float32                  `coachbuf:"2"`
+	Stats versionedStatsV2         `coachbuf:"3"`
+	Guild coachbuf.Optional[int32] `coachbuf:"4,min=0,max=1000"`
+}
+
+// versionedPacket is versioned by its struct option, every field has a fixed width
+type versionedPacket struct {
+	_     struct{} `coachbuf:"versioned"`
+	Kind  int32    `coachbuf:"1,min=0,max=3"`
+	Value float32  `coachbuf:"2"`
+}
+
+func TestVersioned(t *testing.T) {
+	input := versionedPlayerV2{
+		ID:    -7,
+		Score: 1.5,
+		Stats: versionedStatsV2{Health: 90, Mana: 300, Buffs: blobBytes("haste")},
+		Guild: coachbuf.Some[int32](12),
+	}
+	data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(input)
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	t.Run("newer data into older version", func(t *testing.T) {
+		t.Parallel()
+
+		var result versionedPlayerV1
+		if err := (coachbuf.DecodeOptions{Versioned: true, Strict: true}).Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if result.ID != input.ID || result.Stats.Health != input.Stats.Health {
+			t.Errorf("Decode() = %v, want ID=%v and Health=%v", result, input.ID, input.Stats.Health)
+		}
+
+		// Score and Guild are captured, the fields unknown to Stats are skipped
+		reencoded, err := coachbuf.EncodeOptions{Versioned: true}.Encode(result)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var roundTrip versionedPlayerV2
+		if err := (coachbuf.DecodeOptions{Versioned: true}).Decode(reencoded, &roundTrip); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		want := input
+		want.Stats = versionedStatsV2{Health: input.Stats.Health}
+		if roundTrip.ID != want.ID || roundTrip.Score != want.Score || roundTrip.Guild != want.Guild ||
+			roundTrip.Stats.Health != want.Stats.Health || roundTrip.Stats.Mana != 0 || len(roundTrip.Stats.Buffs) != 0 {
+			t.Errorf("Decode() = %v, want %v", roundTrip, want)
+		}
+	})
+
+	t.Run("older data into newer version", func(t *testing.T) {
+		t.Parallel()
+
+		older, err := coachbuf.EncodeOptions{Versioned: true}.Encode(versionedPlayerV1{ID: 3, Stats: versionedStatsV1{Health: 10}})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result versionedPlayerV2
+		if err := (coachbuf.DecodeOptions{Versioned: true}).Decode(older, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if want := (versionedPlayerV2{ID: 3, Stats: versionedStatsV2{Health: 10}}); result.ID != want.ID ||
+			result.Score != want.Score || result.Stats.Health != want.Stats.Health || result.Guild != (coachbuf.Optional[int32]{}) {
+			t.Errorf("Decode() = %v, want %v", result, want)
+		}
+	})
+
+	t.Run("DecodeFields skips by length", func(t *testing.T) {
+		t.Parallel()
+
+		var result versionedPlayerV2
+		opts := coachbuf.DecodeOptions{Versioned: true, Fields: coachbuf.Fields(4, "Stats.Mana")}
+		if err := opts.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if result.ID != 0 || result.Score != 0 || result.Stats.Mana != input.Stats.Mana || result.Guild != input.Guild {
+			t.Errorf("Decode() = %v, want Mana=%v and Guild=%v only", result, input.Stats.Mana, input.Guild)
+		}
+	})
+
+	t.Run("struct option", func(t *testing.T) {
+		t.Parallel()
+
+		input := versionedPacket{Kind: 2, Value: -0.25}
+		data, err := coachbuf.Encode(input)
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result versionedPacket
+		if err := coachbuf.Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if result != input {
+			t.Errorf("Decode() = %v, want %v", result, input)
+		}
+
+		// field count of 9 bits, then 8 bits of ordering number and 6 bits of value header before each value
+		want := 9 + (8 + 6 + 2) + (8 + 6 + 32)
+		if size, err := coachbuf.SizeBits(input); err != nil || size != want {
+			t.Errorf("SizeBits() = %v, %v, want %v", size, err, want)
+		}
+		if maxBits, err := coachbuf.MaxBits[versionedPacket](); err != nil || maxBits != want {
+			t.Errorf("MaxBits() = %v, %v, want %v", maxBits, err, want)
+		}
+	})
+}
+
+func TestVersionedDeepNesting(t *testing.T) {
+	// every level holds the next one, the innermost level holds an int32
+	// each nested struct is measured for its value header, measuring it by encoding it again at every level
+	// would take 2^depth encodes
+	rt := reflect.TypeOf(int32(0))
+	for i := 0; i < 60; i++ {
+		rt = reflect.StructOf([]reflect.StructField{{Name: "Next", Type: rt, Tag: `coachbuf:"1,enc=varint"`}})
+	}
+	input := reflect.New(rt)
+	inner := input.Elem()
+	for inner.Kind() == reflect.Struct {
+		inner = inner.Field(0)
+	}
+	inner.SetInt(-300)
+
+	data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(input.Elem().Interface())
+	if err != nil {
+		t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+	}
+
+	result := reflect.New(rt)
+	if err := (coachbuf.DecodeOptions{Versioned: true, Strict: true}).Decode(data, result.Interface()); err != nil {
+		t.Fatalf("Decode() = %v, want %v", err, nil)
+	}
+	if !reflect.DeepEqual(result.Elem().Interface(), input.Elem().Interface()) {
+		t.Errorf("Decode() = %v, want %v", result.Elem(), input.Elem())
+	}
+}
+
+func TestVersionedErrors(t *testing.T) {
+	t.Run("positional and versioned", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := (coachbuf.EncodeOptions{Positional: true, Versioned: true}).Encode(versionedStatsV1{}); !errors.Is(err, coachbuf.ErrInvalidOptions) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrInvalidOptions)
+		}
+		var result versionedStatsV1
+		if err := (coachbuf.DecodeOptions{Positional: true, Versioned: true}).Decode(make([]byte, 4), &result); !errors.Is(err, coachbuf.ErrInvalidOptions) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrInvalidOptions)
+		}
+
+		type Both struct {
+			_ struct{} `coachbuf:"positional,versioned"`
+		}
+		if _, err := coachbuf.Encode(Both{}); !errors.Is(err, coachbuf.ErrInvalidTagFormat) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrInvalidTagFormat)
+		}
+	})
+
+	t.Run("field length mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		// Health changed from 7 to 10 bits, the type of a field must not change between versions
+		type Widened struct {
+			Health int32 `coachbuf:"1,min=0,max=1000"`
+		}
+		data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(Widened{Health: 900})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result versionedStatsV1
+		if err := (coachbuf.DecodeOptions{Versioned: true}).Decode(data, &result); !errors.Is(err, coachbuf.ErrFieldLength) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrFieldLength)
+		}
+	})
+
+	t.Run("fixed width field written with a length", func(t *testing.T) {
+		t.Parallel()
+
+		// 8 is written as a varint of 10 bits, the same number of bits as Health
+		type Varint struct {
+			Health int32 `coachbuf:"1,enc=varint"`
+		}
+		type Fixed struct {
+			Health int32 `coachbuf:"1,min=0,max=1000"`
+		}
+		data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(Varint{Health: 8})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result Fixed
+		if err := (coachbuf.DecodeOptions{Versioned: true}).Decode(data, &result); err != nil {
+			t.Errorf("Decode() = %v, want %v", err, nil)
+		}
+		if err := (coachbuf.DecodeOptions{Versioned: true, Strict: true}).Decode(data, &result); !errors.Is(err, coachbuf.ErrNonCanonical) {
+			t.Errorf("Decode() = %v, want %v", err, coachbuf.ErrNonCanonical)
+		}
+	})
+
+	t.Run("Unknown written in another layout", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := coachbuf.EncodeOptions{Versioned: true}.Encode(versionedPlayerV2{Score: 1})
+		if err != nil {
+			t.Fatalf("Encode() = %v, want %v", err.Error(), nil)
+		}
+
+		var result versionedPlayerV1
+		if err := (coachbuf.DecodeOptions{Versioned: true}).Decode(data, &result); err != nil {
+			t.Fatalf("Decode() = %v, want %v", err, nil)
+		}
+		if _, err := coachbuf.Encode(result); !errors.Is(err, coachbuf.ErrLayoutMismatch) {
+			t.Errorf("Encode() = %v, want %v", err, coachbuf.ErrLayoutMismatch)
+		}
+	})
+}